@@ -0,0 +1,437 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"cfddns/internal/notify"
+)
+
+type Config struct {
+	CFApiToken         string `toml:"cf_api_token" secret:"true"` // 支持 env:NAME、file:/path、exec:command 引用
+	CFApiTokenFile     string `toml:"cf_api_token_file"`          // 从文件读取 cf_api_token，优先于 cf_api_token
+	CFZoneID           string `toml:"cf_zone_id"`
+	CFRecordName       string `toml:"cf_record_name"`
+	CFIPType           string `toml:"cf_ip_type"`
+	AddRecordIfMissing bool   `toml:"add_record_if_missing"`
+	Duplicates         string `toml:"duplicates"` // 同名同类型存在多条记录时的处理策略
+	Interval           int    `toml:"interval"`
+	KeepRetry          int    `toml:"keep_retry"`
+	RetryCount         int    `toml:"retry_count"`
+	GetIPv4URL         string `toml:"get_ipv4_url"`
+	GetIPv6URL         string `toml:"get_ipv6_url"`
+	IPQuorum           int    `toml:"ip_quorum"` // 至少多少个来源返回相同 IP 才更新
+	Notify             bool   `toml:"notify"`
+	TgApiUrl           string `toml:"tg_api_url"` // 将 TG_PROXY_URL 改为 TG_API_URL
+	TGToken            string `toml:"tg_token" secret:"true"`
+	TGTokenFile        string `toml:"tg_token_file"` // 从文件读取 tg_token，优先于 tg_token
+	TGChatID           string `toml:"tg_chat_id"`
+	Debug              bool   `toml:"debug"`
+	DryRun             bool   `toml:"dry_run"`             // 演练模式，只查询不修改 DNS 记录
+	NotifyDryRun       bool   `toml:"notify_dry_run"`      // 演练模式下是否发送通知
+	ExitCodeOnChange   bool   `toml:"exit_code_on_change"` // once 等单次命令有记录被更新时以 10 退出
+	LogPath            string `toml:"log_path"`
+	LogRetention       int    `toml:"log_retention"`           // 日志保留天数
+	LogFormat          string `toml:"log_format"`              // 日志格式：text 或 json
+	StateDir           string `toml:"state_dir"`               // 状态文件目录
+	ResyncInterval     int    `toml:"resync_interval"`         // 强制与 Cloudflare 重新同步的间隔，单位为秒
+	ShutdownTimeout    int    `toml:"shutdown_timeout"`        // 退出时等待进行中请求的时间，单位为秒
+	NotifyShutdown     bool   `toml:"notify_shutdown"`         // 退出时是否发送通知
+	Listen             string `toml:"listen"`                  // HTTP API 监听地址，如 127.0.0.1:8053，留空不启用
+	APIToken           string `toml:"api_token" secret:"true"` // POST /trigger 使用的 Bearer Token，留空则禁用
+	APITokenFile       string `toml:"api_token_file"`          // 从文件读取 api_token，优先于 api_token
+
+	Records     []RecordConfig   `toml:"records"`      // 多记录配置，为空时使用 cf_zone_id/cf_record_name/cf_ip_type
+	IPv4Sources []IPSourceConfig `toml:"ipv4_sources"` // IPv4 获取来源，为空时使用 get_ipv4_url
+	IPv6Sources []IPSourceConfig `toml:"ipv6_sources"` // IPv6 获取来源，为空时使用 get_ipv6_url
+	Notifiers   []notify.Config  `toml:"notifiers"`    // 通知渠道，notify = true 时 tg_* 配置作为名为 telegram 的渠道
+
+	lines   map[string]int    // 配置项所在的行号，用于校验时提示
+	sources map[string]string // 通过环境变量或命令行参数覆盖的配置项及其来源
+
+	plaintextSecrets bool // 配置文件中是否直接写有密钥
+}
+
+// RecordConfig 单条 DNS 记录的配置
+type RecordConfig struct {
+	ZoneID string `toml:"zone_id"` // 留空则使用 cf_zone_id，都为空时根据 name 自动查找
+	Name   string `toml:"name"`
+	IPType string `toml:"ip_type"` // 留空则使用 cf_ip_type
+	// 同名同类型存在多条记录时的处理策略：update_first、update_all、replace、fail，留空则使用 duplicates
+	Duplicates string `toml:"duplicates"`
+
+	// 以下设置只有显式配置时才会强制同步，未配置时保留 Cloudflare 上的现有值
+	TTL     *int      `toml:"ttl"`
+	Proxied *bool     `toml:"proxied"`
+	Comment *string   `toml:"comment"`
+	Tags    *[]string `toml:"tags"`
+
+	key      string // 配置中的位置，如 records[0]，兼容旧版本的单记录配置时为空
+	zoneName string // 自动查找到的 zone 名称，zone_id 为手动配置时为空
+}
+
+// defaultConfigPath 默认的配置文件路径
+const defaultConfigPath = "conf.toml"
+
+// loadConfig 启动时加载配置
+// 配置文件不存在，且没有通过环境变量或命令行参数提供配置时，创建默认配置文件
+func loadConfig(confPath string, flags map[string]string) (Config, error) {
+	// 检查配置文件是否存在
+	if _, err := os.Stat(confPath); os.IsNotExist(err) && len(flags) == 0 && !hasEnvOverrides() {
+		slog.Info("Config file not found. Creating a default config file", "path", confPath)
+		if err := createDefaultConfig(confPath); err != nil {
+			return Config{}, err
+		}
+	}
+
+	config, err := readConfig(confPath, flags)
+	if err != nil {
+		return Config{}, err
+	}
+	warnConfigPermissions(confPath, config)
+	return config, nil
+}
+
+// readConfig 生成配置并填充默认值
+// 优先级：命令行参数 > 环境变量（CFDDNS_*）> 配置文件 > 默认值，配置文件不存在时跳过
+func readConfig(confPath string, flags map[string]string) (Config, error) {
+	var config Config
+
+	// 读取配置文件
+	data, err := os.ReadFile(confPath)
+	switch {
+	case err == nil:
+		// 解析配置文件
+		if err := toml.Unmarshal(data, &config); err != nil {
+			return Config{}, fmt.Errorf("error parsing config: %w", err)
+		}
+		config.lines = configLines(data)
+		config.plaintextSecrets = hasPlaintextSecrets(&config)
+	case os.IsNotExist(err):
+		// 没有配置文件时只使用环境变量和命令行参数
+	default:
+		return Config{}, fmt.Errorf("error reading config: %w", err)
+	}
+
+	if err := applyOverrides(&config, flags); err != nil {
+		return Config{}, err
+	}
+
+	// 读取 *_file 以及 env:/file:/exec: 引用的密钥
+	if err := resolveSecrets(&config); err != nil {
+		return Config{}, fmt.Errorf("error reading secret: %w", err)
+	}
+
+	// 如果未配置 RetryCount，设置默认值
+	if config.RetryCount == 0 {
+		config.RetryCount = 3
+	}
+
+	// 如果未设置执行间隔，默认1分钟
+	if config.Interval == 0 {
+		config.Interval = 60
+	}
+
+	// 如果未设置 TG_API_URL，留空使用默认值
+	if config.TgApiUrl == "" {
+		config.TgApiUrl = "https://api.telegram.org"
+	}
+
+	// 如果未设置 GetIPv4URL，留空使用默认值https://4.ipw.cn
+	if config.GetIPv4URL == "" {
+		config.GetIPv4URL = "https://4.ipw.cn"
+	}
+
+	// 如果未设置状态文件目录，默认使用当前目录
+	if config.StateDir == "" {
+		config.StateDir = "."
+	}
+
+	// 如果未设置强制同步间隔，默认1小时
+	if config.ResyncInterval == 0 {
+		config.ResyncInterval = 3600
+	}
+
+	// 如果未设置退出等待时间，默认10秒
+	if config.ShutdownTimeout == 0 {
+		config.ShutdownTimeout = 10
+	}
+
+	// 如果未设置日志保留天数，默认保留7天
+	if config.LogRetention == 0 {
+		config.LogRetention = 7
+	}
+
+	// 未配置 [[records]] 时，使用单记录配置兼容旧版本
+	if len(config.Records) == 0 {
+		if config.CFRecordName != "" {
+			config.Records = []RecordConfig{{Name: config.CFRecordName}}
+		}
+	} else {
+		for i := range config.Records {
+			config.Records[i].key = fmt.Sprintf("records[%d]", i)
+		}
+	}
+	for i := range config.Records {
+		rec := &config.Records[i]
+		if rec.ZoneID == "" {
+			rec.ZoneID = config.CFZoneID
+		}
+		if rec.IPType == "" {
+			rec.IPType = config.CFIPType
+		}
+		if rec.Duplicates == "" {
+			rec.Duplicates = config.Duplicates
+		}
+		if rec.Duplicates == "" {
+			rec.Duplicates = duplicatesUpdateFirst
+		}
+	}
+
+	return config, nil
+}
+
+// createDefaultConfig 写入带注释的默认配置文件
+func createDefaultConfig(configPath string) error {
+	defaultConfig := `
+# Cloudflare API配置
+cf_api_token = "your_CF_API_TOKEN_here"  # Cloudflare API Token
+# 密钥可以不直接写在配置文件中：
+# cf_api_token_file = "/run/secrets/cf_api_token"  # 从文件读取，优先于 cf_api_token，适用于 Docker/Kubernetes secrets
+# cf_api_token = "env:CF_API_TOKEN"                # 从环境变量读取
+# cf_api_token = "file:/etc/cfddns/token"          # 从文件读取
+# cf_api_token = "exec:pass show cloudflare/token"  # 执行命令并读取输出，如 pass、vault、secret-tool
+cf_zone_id = "Your_CF_ZONE_ID_HERE"    # Cloudflare Zone ID，留空则根据 cf_record_name 自动查找并缓存到状态文件
+cf_record_name = "YOUR_DOMAIN_HERE"  # 要更新的记录名称
+
+# IP类型，用于指定获取IPv4还是IPv6
+cf_ip_type = "46"  # 支持值：4（仅更新 IPv4），6（仅更新 IPv6），46（同时更新 IPv4 和 IPv6）
+
+# 如果 DNS 记录不存在，是否自动添加
+add_record_if_missing = true
+
+# 同名同类型存在多条记录时的处理策略
+# update_first：只更新第一条（默认）；update_all：全部更新为同一个 IP；
+# replace：保留一条并删除其他记录；fail：不做修改并报错
+# update_first 和 fail 对同一组重复记录只发送一次 duplicates 通知
+duplicates = "update_first"
+
+# 执行间隔，单位为秒
+interval = 60  # 每1分钟执行一次
+
+# 状态文件目录，用于缓存记录 ID 和最后推送的 IP，IP 未变化时不再查询 Cloudflare
+state_dir = "."
+# 强制与 Cloudflare 重新同步的间隔，单位为秒，用于发现在控制台中手动修改的记录
+resync_interval = 3600
+# 收到退出信号后，等待进行中的 Cloudflare 请求完成的最长时间，单位为秒
+shutdown_timeout = 10
+
+# IP获取一直重试
+# 1为一直重试，其他为不一直重试
+keep_retry = 1
+# IP获取重试次数
+retry_count = 3
+
+# 获取IPv4地址的URL
+get_ipv4_url = "https://4.ipw.cn"
+
+# 获取IPv6地址的URL
+get_ipv6_url = "https://6.ipw.cn"
+# 获取公网 IPv4 和 IPv6 地址的 URL,备选
+# get_ipv4_url = "https://api64.ipify.org"
+# get_ipv6_url = "https://api6.ipify.org"
+
+# 配置了多个 IP 获取来源（见文末 ipv4_sources/ipv6_sources）时，
+# 至少需要多少个来源返回相同的 IP 才会更新，0 或 1 表示使用第一个成功返回合法 IP 的来源
+ip_quorum = 0
+
+# Telegram配置，更多通知渠道见文末 [[notifiers]]
+# 变动推送通知,1通知，0不通知
+notify = false
+tg_api_url = ""  # 自定义 Telegram API URL，如果不需要，留空
+tg_token = "Your_tg_bot_token_here"
+# tg_token_file = "/run/secrets/tg_token"  # 从文件读取，优先于 tg_token；tg_token 同样支持 env:/file:/exec: 引用
+tg_chat_id = "Your_tg_chat_id_here"
+# 程序退出时是否发送通知
+notify_shutdown = false
+
+# 本地 HTTP API，提供 GET /healthz、GET /status、GET /metrics（Prometheus）和 POST /trigger（立即同步），留空不启用
+# 只在本机使用时请监听 127.0.0.1，修改后需要重启程序
+listen = ""
+# POST /trigger 需要的 Bearer Token，留空则禁用 /trigger，同样支持 api_token_file 和 env:/file:/exec: 引用
+api_token = ""
+
+# 调试模式，开启后输出调试日志并记录访问 Cloudflare 和 IP 来源的 HTTP 请求和响应（隐藏 Token），不记录通知请求
+debug = false
+
+# 演练模式，获取 IP 并查询 Cloudflare，但不创建、修改或删除记录，只输出将要执行的修改
+dry_run = false
+# 演练模式下是否仍然发送通知
+notify_dry_run = false
+
+# once、v4、v6、v46 等单次命令的退出码：0 无需更新，2 获取 IP 失败，3 Cloudflare 错误，4 配置错误
+# 开启后有记录被更新时以 10 退出（默认 0），便于 cron 脚本判断
+exit_code_on_change = false
+
+# 日志设置，设置目录后日志按天写入 cfddns-YYYY-MM-DD.log，同时输出到控制台
+log_path = ''
+# 日志保存时间，默认7天
+log_retention = 7
+# 日志格式，text 或 json
+log_format = "text"
+
+# 多记录配置，配置后将忽略上方的 cf_record_name
+# 每条记录可单独指定 zone_id、ip_type、duplicates，留空则使用上方的全局配置
+# ttl、proxied、comment、tags 只有设置时才会强制同步，未设置时保留 Cloudflare 上的现有值
+# [[records]]
+# zone_id = "Your_CF_ZONE_ID_HERE"
+# name = "home.example.com"
+# ip_type = "46"
+# ttl = 1800
+# proxied = false
+# comment = "managed by cfddns"
+# tags = ["ddns:home"]
+#
+# [[records]]
+# name = "nas.example.com"
+# ip_type = "6"
+# duplicates = "replace"
+
+# 多个 IP 获取来源，按顺序尝试，配置后将忽略 get_ipv4_url/get_ipv6_url
+# 返回内容必须是合法的 IP 地址，否则视为该来源失败
+# [[ipv4_sources]]
+# url = "https://4.ipw.cn"
+# [[ipv4_sources]]
+# url = "https://api.ipify.org"
+#
+# [[ipv6_sources]]
+# url = "https://6.ipw.cn"
+# [[ipv6_sources]]
+# url = "https://api6.ipify.org"
+
+# 也可以直接从本机网卡读取 IP，无需访问外部服务
+# filter 可以是 CIDR 或正则，prefer 可选 stable（默认）或 temporary，skip_deprecated 默认 true
+# 默认跳过内网地址（10/8、172.16/12、192.168/16、100.64/10、fc00::/7），确实需要发布内网地址时设置 allow_private = true
+# [[ipv6_sources]]
+# type = "interface"
+# interface = "eth0"
+# filter = "2000::/3"
+# prefer = "stable"
+# skip_deprecated = true
+# allow_private = false
+
+# 通知渠道，一个事件可以同时发送到多个渠道，可与上方的 notify/tg_* 配置同时使用
+# notify = true 时 tg_* 配置相当于一个名为 telegram 的渠道，因此这里的 name 不能再使用 telegram
+# events 可选 update_success、update_failure、ip_failure、duplicates、startup、shutdown，留空表示全部事件
+# 渠道的设置写在与 type 同名的子表中，token 同样支持 token_file 和 env:/file:/exec: 引用
+# [[notifiers]]
+# name = "ops"
+# type = "telegram"
+# events = ["update_failure", "ip_failure", "shutdown"]
+# [notifiers.telegram]
+# token = "env:OPS_TG_TOKEN"
+# chat_id = "-1001234567890"
+# api_url = ""  # 自定义 Telegram API URL，如果不需要，留空
+#
+# 通用 Webhook，body 为 Go text/template 模板，可用字段：.Type .Time .Hostname .Message .Record .IPType
+# .OldIP .NewIP .Success .Error .DryRun，{{json .Message}} 输出转义后的 JSON 字符串；留空则发送事件的 JSON
+# 设置 secret 后使用 HMAC-SHA256 对请求体签名，写入 X-CfDDNS-Signature: sha256=<hex>
+# [[notifiers]]
+# name = "alerts"
+# type = "webhook"
+# events = ["update_success", "update_failure"]
+# [notifiers.webhook]
+# url = "https://alerts.example.com/hooks/cfddns"
+# method = "POST"
+# headers = { Authorization = "Bearer xxx" }
+# body = '''{"text": {{json .Message}}, "record": {{json .Record}}, "ok": {{.Success}}, "at": "{{.Time.Format "2006-01-02T15:04:05Z07:00"}}"}'''
+# secret = "env:WEBHOOK_SECRET"
+#
+# 邮件（SMTP），tls 可选 starttls（默认，端口 587）、tls（端口 465）或 none（端口 25，仅限本机或可信网络）
+# auth 可选 plain（默认）或 login，未设置 username 时不认证；password 同样支持 password_file 和 env:/file:/exec: 引用
+# subject、body 为 text/template 模板，可用字段同 webhook，留空使用默认模板
+# [[notifiers]]
+# name = "mail"
+# type = "email"
+# events = ["update_failure", "ip_failure"]
+# [notifiers.email]
+# host = "smtp.example.com"
+# port = 587
+# tls = "starttls"
+# username = "ddns@example.com"
+# password = "env:SMTP_PASSWORD"
+# from = "CfDDNS <ddns@example.com>"
+# to = ["admin@example.com", "ops@example.com"]
+# subject = "[CfDDNS] {{.Type}} {{.Record}} on {{.Hostname}}"
+# body = "{{.Message}}"
+#
+# 国内推送服务：serverchan（Server酱）、pushplus、wecom（企业微信群机器人）、dingtalk（钉钉群机器人）、
+# feishu（飞书群机器人）、bark，每个 [[notifiers]] 配置一个，以下只列出各自的子表
+# 密钥和 Webhook 地址同样支持 *_file 和 env:/file:/exec: 引用
+# [notifiers.serverchan]
+# send_key = "SCTxxx"  # 支持 Server酱 Turbo 和 Server酱³（sctp 开头）的 SendKey
+#
+# [notifiers.pushplus]
+# token = "xxx"
+# topic = ""  # 群组编码，留空发送给自己
+#
+# [notifiers.wecom]
+# webhook_url = "https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=xxx"
+# mentioned_mobiles = ["13800000000"]  # @all 表示所有人
+#
+# [notifiers.dingtalk]
+# webhook_url = "https://oapi.dingtalk.com/robot/send?access_token=xxx"
+# secret = "SECxxx"  # 安全设置为“加签”时填写
+# at_mobiles = ["13800000000"]
+# at_all = false
+#
+# [notifiers.feishu]
+# webhook_url = "https://open.feishu.cn/open-apis/bot/v2/hook/xxx"
+# secret = "xxx"  # 安全设置为“签名校验”时填写
+#
+# [notifiers.bark]
+# server = ""  # 自建服务器地址，留空使用 https://api.day.app
+# device_key = "xxx"
+# group = "cfddns"
+# level = "timeSensitive"  # active、timeSensitive、passive 或 critical
+#
+# Slack、Discord 以及自建的 Matrix、Gotify、ntfy，同样每个 [[notifiers]] 配置一个，以下只列出各自的子表
+# 配置后可以使用 cfddns notify-test <name> 发送测试消息
+# [notifiers.slack]
+# webhook_url = "https://hooks.slack.com/services/T000/B000/XXX"
+#
+# [notifiers.discord]
+# webhook_url = "https://discord.com/api/webhooks/123/xxx"
+# username = "CfDDNS"
+#
+# [notifiers.matrix]
+# homeserver = "https://matrix.example.com"
+# access_token = "env:MATRIX_TOKEN"
+# room_id = "!abcdef:example.com"
+# msgtype = "m.notice"  # m.notice 或 m.text
+#
+# [notifiers.gotify]
+# server = "https://gotify.example.com"
+# token = "xxx"  # 应用 Token
+# priority = 5
+#
+# [notifiers.ntfy]
+# server = ""  # 自建服务器地址，留空使用 https://ntfy.sh
+# topic = "cfddns"
+# token = ""  # 访问令牌，也可以使用 username/password
+# priority = "default"  # min、low、default、high、max 或 1-5
+# tags = ["globe_with_meridians"]
+
+`
+	// 写入默认配置文件
+	err := os.WriteFile(configPath, []byte(defaultConfig), 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create default config file: %w", err)
+	}
+
+	slog.Info("Default config file created. Please review and update it as needed.", "path", configPath)
+	return nil
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"time"
+
+	"cfddns/internal/notify"
+)
+
+// configPollInterval 检查配置文件是否被修改的间隔
+const configPollInterval = 5 * time.Second
+
+// run 以守护进程方式运行，每隔 interval 秒同步一次，ctx 取消后退出
+// 收到 SIGUSR1 或 POST /trigger 时立即执行一次同步，收到 SIGHUP 或配置文件被修改时重新加载配置
+func (cf *CfDDNS) run(ctx context.Context) {
+	trigger := make(chan os.Signal, 1)
+	notifyTrigger(trigger)
+	reload := make(chan os.Signal, 1)
+	notifyReload(reload)
+
+	// 配置了 listen 时启动 HTTP API，监听失败不影响同步
+	listen := cf.Config.Listen
+	if listen != "" {
+		if err := cf.serveAPI(ctx, listen); err != nil {
+			slog.Error("Failed to start HTTP API", "addr", listen, "error", err)
+		}
+	}
+
+	poll := time.NewTicker(configPollInterval)
+	defer poll.Stop()
+	lastModTime := configModTime(cf.configPath)
+
+	hostname, _ := os.Hostname()
+	cf.notify(ctx, notify.Event{Type: notify.EventStartup, Hostname: hostname, Message: "CfDDNS " + Version + " on " + hostname + " started."})
+
+	for {
+		cf.updateDNSRecord(ctx, "")
+		if ctx.Err() != nil {
+			break
+		}
+
+		slog.Info("Waiting before the next check.", "seconds", cf.Config.Interval)
+		timer := time.NewTimer(time.Duration(cf.Config.Interval) * time.Second)
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				break wait
+			case sig := <-trigger:
+				slog.Info("Received signal, running an immediate check.", "signal", sig.String())
+				break wait
+			case source := <-cf.triggers:
+				slog.Info("Immediate check requested.", "source", string(source))
+				break wait
+			case sig := <-reload:
+				slog.Info("Received signal, reloading config.", "signal", sig.String())
+				lastModTime = configModTime(cf.configPath)
+				if cf.reloadConfig() {
+					break wait
+				}
+			case <-poll.C:
+				modTime := configModTime(cf.configPath)
+				if modTime.Equal(lastModTime) {
+					continue
+				}
+				lastModTime = modTime
+				slog.Info("Config file changed, reloading config.", "path", cf.configPath)
+				if cf.reloadConfig() {
+					break wait
+				}
+			case <-timer.C:
+				break wait
+			}
+		}
+		timer.Stop()
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	slog.Info("Shutting down.")
+	// ctx 已取消，使用独立的超时发送退出通知
+	notifyCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Duration(cf.Config.ShutdownTimeout)*time.Second)
+	defer cancel()
+	cf.notify(notifyCtx, notify.Event{Type: notify.EventShutdown, Hostname: hostname, Message: "CfDDNS on " + hostname + " is shutting down."})
+}
+
+// reloadConfig 重新读取配置文件，新配置无效时继续使用当前配置
+func (cf *CfDDNS) reloadConfig() bool {
+	if cf.configPath == "" {
+		return false
+	}
+	config, err := readConfig(cf.configPath, cf.flagOverrides)
+	if err == nil {
+		err = config.Validate()
+	}
+	if err != nil {
+		logConfigErrors("Failed to reload config, keeping the current config", cf.configPath, err)
+		return false
+	}
+
+	if err := setupLogging(config); err != nil {
+		slog.Error("Failed to set up log file", "error", err)
+	}
+	if config.Listen != cf.Config.Listen {
+		slog.Warn("The listen address changes after a restart.", "current", cf.Config.Listen, "configured", config.Listen)
+	}
+	cf.applyConfig(config)
+	slog.Info("Config reloaded.", "path", cf.configPath, "records", len(config.Records))
+	return true
+}
+
+// configModTime 返回配置文件的修改时间，文件不存在时返回零值
+func configModTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// graceContext 返回一个在 parent 取消后再等待 timeout 才取消的 context，
+// 用于让进行中的 Cloudflare 请求在退出前完成
+func graceContext(parent context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(parent))
+	stop := context.AfterFunc(parent, func() {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+			cancel()
+		case <-ctx.Done():
+		}
+	})
+	return ctx, func() {
+		stop()
+		cancel()
+	}
+}
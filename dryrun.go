@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"cfddns/internal/cloudflare"
+)
+
+// dryRunPrefix 演练模式下输出的计划和通知的前缀
+const dryRunPrefix = "[DRY RUN]"
+
+// planOutput 演练计划的输出位置
+var planOutput io.Writer = os.Stdout
+
+// printPlan 演练模式下输出将要执行的修改，代替 POST/PATCH/DELETE 请求
+// action 为 create、update 或 delete；create 时 old 为 nil，delete 时只输出 old
+func printPlan(action string, old *cloudflare.DNSRecord, record cloudflare.DNSRecord) {
+	id := ""
+	if old != nil && old.ID != "" {
+		id = " (id " + old.ID + ")"
+	}
+
+	var content, ttl, proxied string
+	switch {
+	case old == nil:
+		content, ttl, proxied = record.Content, ttlString(record.TTL), strconv.FormatBool(record.Proxied)
+	case action == "delete":
+		content, ttl, proxied = old.Content, ttlString(old.TTL), strconv.FormatBool(old.Proxied)
+	default:
+		content = change(old.Content, record.Content)
+		ttl = change(ttlString(old.TTL), ttlString(record.TTL))
+		proxied = change(strconv.FormatBool(old.Proxied), strconv.FormatBool(record.Proxied))
+	}
+	fmt.Fprintf(planOutput, "%s %s %s %s%s: content=%s ttl=%s proxied=%s\n", dryRunPrefix, action, record.Type, record.Name, id, content, ttl, proxied)
+}
+
+// ttlString 格式化 TTL，1 和 0（未设置）表示由 Cloudflare 自动决定
+func ttlString(ttl int) string {
+	if ttl <= 1 {
+		return "auto"
+	}
+	return strconv.Itoa(ttl)
+}
+
+// change 格式化修改前后的值，未变化时只输出一次
+func change(old, new string) string {
+	if old == new {
+		return old
+	}
+	return old + " -> " + new
+}
+
+// applyPatch 返回 record 应用 patch 后的结果
+func applyPatch(record cloudflare.DNSRecord, patch cloudflare.DNSRecordPatch) cloudflare.DNSRecord {
+	if patch.Content != "" {
+		record.Content = patch.Content
+	}
+	if patch.TTL != nil {
+		record.TTL = *patch.TTL
+	}
+	if patch.Proxied != nil {
+		record.Proxied = *patch.Proxied
+	}
+	if patch.Comment != nil {
+		record.Comment = *patch.Comment
+	}
+	if patch.Tags != nil {
+		record.Tags = *patch.Tags
+	}
+	return record
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"cfddns/internal/cloudflare"
+)
+
+// capturePlan 把演练计划写入返回的 buffer，测试结束时恢复
+func capturePlan(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	planOutput = &buf
+	t.Cleanup(func() { planOutput = os.Stdout })
+	return &buf
+}
+
+func TestPrintPlan(t *testing.T) {
+	old := cloudflare.DNSRecord{ID: "r1", Type: "A", Name: "a.example.com", Content: "192.0.2.1", TTL: 1}
+	tests := []struct {
+		action string
+		old    *cloudflare.DNSRecord
+		record cloudflare.DNSRecord
+		want   string
+	}{
+		{"create", nil, cloudflare.DNSRecord{Type: "A", Name: "a.example.com", Content: "192.0.2.2", TTL: 300, Proxied: true},
+			"[DRY RUN] create A a.example.com: content=192.0.2.2 ttl=300 proxied=true\n"},
+		{"update", &old, cloudflare.DNSRecord{Type: "A", Name: "a.example.com", Content: "192.0.2.2", TTL: 1},
+			"[DRY RUN] update A a.example.com (id r1): content=192.0.2.1 -> 192.0.2.2 ttl=auto proxied=false\n"},
+		{"update", &old, cloudflare.DNSRecord{Type: "A", Name: "a.example.com", Content: "192.0.2.1", TTL: 60},
+			"[DRY RUN] update A a.example.com (id r1): content=192.0.2.1 ttl=auto -> 60 proxied=false\n"},
+		{"delete", &old, old,
+			"[DRY RUN] delete A a.example.com (id r1): content=192.0.2.1 ttl=auto proxied=false\n"},
+	}
+	for _, tt := range tests {
+		buf := capturePlan(t)
+		printPlan(tt.action, tt.old, tt.record)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("printPlan(%s) = %q, want %q", tt.action, got, tt.want)
+		}
+	}
+}
+
+func TestDryRunMakesNoChanges(t *testing.T) {
+	bRecord := cloudflare.DNSRecord{ID: "r2", ZoneID: fakeZoneID, Type: "A", Name: "b.example.com", Content: "192.0.2.1"}
+	dup := bRecord
+	dup.ID, dup.Content = "r3", "192.0.2.9"
+	f := newFakeCloudflare(t, aRecord("r1", "192.0.2.1"), bRecord, dup)
+	ttl := 60
+	cf := newTestCfDDNS(t, f, Config{
+		DryRun:             true,
+		AddRecordIfMissing: true,
+		Records: []RecordConfig{
+			{Name: "a.example.com", IPType: "4", TTL: &ttl},
+			{Name: "b.example.com", IPType: "4", Duplicates: duplicatesReplace},
+			{Name: "c.example.com", IPType: "4"},
+		},
+	})
+	events := recordEvents(cf)
+	buf := capturePlan(t)
+	ctx := context.Background()
+
+	for _, rec := range cf.Config.Records {
+		if outcome, err := cf.syncRecord(ctx, rec, "4", "192.0.2.2"); err != nil || outcome != syncUpdated {
+			t.Errorf("%s: outcome = %v, %v, want updated", rec.Name, outcome, err)
+		}
+	}
+
+	want := []string{
+		"[DRY RUN] update A a.example.com (id r1): content=192.0.2.1 -> 192.0.2.2 ttl=auto -> 60 proxied=false",
+		"[DRY RUN] delete A b.example.com (id r3): content=192.0.2.9 ttl=auto proxied=false",
+		"[DRY RUN] update A b.example.com (id r2): content=192.0.2.1 -> 192.0.2.2 ttl=auto proxied=false",
+		"[DRY RUN] create A c.example.com: content=192.0.2.2 ttl=auto proxied=false",
+	}
+	if got := strings.Split(strings.TrimSpace(buf.String()), "\n"); !slices.Equal(got, want) {
+		t.Errorf("plan =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if n := f.count("POST") + f.count("PATCH") + f.count("DELETE"); n != 0 {
+		t.Errorf("dry run made %d write requests", n)
+	}
+	for _, rec := range cf.Config.Records {
+		if _, ok := cf.state.get(stateKey(rec, "4")); ok {
+			t.Errorf("%s: dry run wrote the state cache", rec.Name)
+		}
+	}
+	if got := events.types(); len(got) != 0 {
+		t.Errorf("events = %v, want none without notify_dry_run", got)
+	}
+
+	// 开启 notify_dry_run 后发送带标注的通知
+	cf.Config.NotifyDryRun = true
+	cf.syncRecord(ctx, cf.Config.Records[0], "4", "192.0.2.2")
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	if len(events.events) != 1 || !events.events[0].DryRun || !strings.HasPrefix(events.events[0].Message, dryRunPrefix) {
+		t.Errorf("events with notify_dry_run = %+v", events.events)
+	}
+}
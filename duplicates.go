@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"cfddns/internal/cloudflare"
+	"cfddns/internal/notify"
+)
+
+// 同名同类型存在多条记录时的处理策略
+const (
+	duplicatesUpdateFirst = "update_first" // 只更新第一条，其他记录保持不变（默认）
+	duplicatesUpdateAll   = "update_all"   // 全部更新为同一个 IP，适用于有意配置的多条记录
+	duplicatesReplace     = "replace"      // 保留一条并删除其他记录
+	duplicatesFail        = "fail"         // 不做任何修改，报告错误
+)
+
+// validDuplicates 判断 duplicates 的值是否合法
+func validDuplicates(policy string) bool {
+	switch policy {
+	case duplicatesUpdateFirst, duplicatesUpdateAll, duplicatesReplace, duplicatesFail:
+		return true
+	}
+	return false
+}
+
+// handleDuplicates 按记录的 duplicates 策略处理查询到的记录，返回需要同步为 ip 的记录
+// records 为空时返回空列表，由调用方决定是否新建
+func (cf *CfDDNS) handleDuplicates(ctx context.Context, rec RecordConfig, ipType string, records []cloudflare.DNSRecord, ip string) ([]cloudflare.DNSRecord, error) {
+	key := stateKey(rec, ipType)
+	if len(records) <= 1 || (rec.Duplicates != duplicatesFail && rec.Duplicates != duplicatesUpdateFirst) {
+		// 重复记录已经消失或改用了每次都会通知的策略，下次出现时重新通知
+		cf.state.setDuplicates(key, "")
+	}
+	if len(records) <= 1 {
+		return records, nil
+	}
+
+	logger := recordLogger(rec, ipType).With("count", len(records), "duplicates", rec.Duplicates)
+	ips := make([]string, 0, len(records))
+	for _, r := range records {
+		ips = append(ips, r.Content)
+	}
+
+	switch rec.Duplicates {
+	case duplicatesUpdateAll:
+		logger.Info("Multiple DNS records found, updating all of them.", "ips", ips)
+		return records, nil
+
+	case duplicatesReplace:
+		// 优先保留已经指向 ip 的记录，减少一次更新
+		keep := 0
+		if i := slices.IndexFunc(records, func(r cloudflare.DNSRecord) bool { return r.Content == ip }); i >= 0 {
+			keep = i
+		}
+		logger.Warn("Multiple DNS records found, deleting the extra records.", "ips", ips, "keep_record_id", records[keep].ID)
+		deleted := 0
+		for i, r := range records {
+			if i == keep {
+				continue
+			}
+			if cf.Config.DryRun {
+				printPlan("delete", &r, r)
+				deleted++
+				continue
+			}
+			err := cf.api.DeleteDNSRecord(ctx, rec.ZoneID, r.ID)
+			metrics.dnsUpdates.inc(recordTypeOf(ipType), "delete", resultLabel(err))
+			if err != nil {
+				logger.Error("Failed to delete duplicate DNS record", "record_id", r.ID, "ip", r.Content, "error", err)
+				cf.notifyDuplicates(ctx, fmt.Sprintf("Found %d IPv%s DNS records for %s, failed to delete duplicate record %s (%s): %v", len(records), ipType, rec.Name, r.ID, r.Content, err))
+				return nil, err
+			}
+			logger.Info("Deleted duplicate DNS record.", "record_id", r.ID, "ip", r.Content)
+			deleted++
+		}
+		cf.notifyDuplicates(ctx, fmt.Sprintf("Found %d IPv%s DNS records for %s, deleted %d duplicate(s) and kept %s.", len(records), ipType, rec.Name, deleted, records[keep].Content))
+		return records[keep : keep+1], nil
+
+	case duplicatesFail:
+		logger.Error("Multiple DNS records found, not updating.", "ips", ips)
+		cf.notifyDuplicatesOnce(ctx, key, rec, records, fmt.Sprintf("Found %d IPv%s DNS records for %s (%v), not updating because duplicates = \"fail\".", len(records), ipType, rec.Name, ips))
+		return nil, &duplicatesError{count: len(records), recordType: recordTypeOf(ipType), name: rec.Name}
+
+	default:
+		logger.Warn("Multiple DNS records found, updating only the first one.", "ips", ips, "record_id", records[0].ID)
+		cf.notifyDuplicatesOnce(ctx, key, rec, records, fmt.Sprintf("Found %d IPv%s DNS records for %s (%v), only the first one will be updated.", len(records), ipType, rec.Name, ips))
+		return records[:1], nil
+	}
+}
+
+// duplicatesError duplicates = "fail" 时发现多条记录，handleDuplicates 已经发送过重复记录通知
+type duplicatesError struct {
+	count      int
+	recordType string
+	name       string
+}
+
+func (e *duplicatesError) Error() string {
+	return fmt.Sprintf("found %d %s records for %s", e.count, e.recordType, e.name)
+}
+
+// duplicatesFingerprint 返回一组记录的标识，只由记录 ID 决定，更新记录内容不会改变标识
+func duplicatesFingerprint(records []cloudflare.DNSRecord) string {
+	ids := make([]string, 0, len(records))
+	for _, r := range records {
+		ids = append(ids, r.ID)
+	}
+	slices.Sort(ids)
+	return strings.Join(ids, ",")
+}
+
+// notifyDuplicatesOnce 同一组重复记录每次查询都会出现，只在第一次出现、记录集合或策略变化时通知
+func (cf *CfDDNS) notifyDuplicatesOnce(ctx context.Context, key string, rec RecordConfig, records []cloudflare.DNSRecord, message string) {
+	fingerprint := rec.Duplicates + ":" + duplicatesFingerprint(records)
+	if cf.state.duplicatesReported(key, fingerprint) {
+		return
+	}
+	cf.notifyDuplicates(ctx, message)
+	// 演练模式不修改状态文件
+	if !cf.Config.DryRun {
+		cf.state.setDuplicates(key, fingerprint)
+	}
+}
+
+// notifyDuplicates 发送重复记录的处理结果通知
+func (cf *CfDDNS) notifyDuplicates(ctx context.Context, message string) {
+	cf.notify(ctx, notify.Event{Type: notify.EventDuplicates, Message: message})
+}
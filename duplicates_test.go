@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"cfddns/internal/cloudflare"
+	"cfddns/internal/notify"
+)
+
+func TestHandleDuplicatesPolicies(t *testing.T) {
+	tests := []struct {
+		policy  string
+		records []cloudflare.DNSRecord
+		outcome syncOutcome
+		want    []string // 同步后 Cloudflare 上的记录
+		events  []notify.EventType
+	}{
+		{
+			duplicatesUpdateFirst,
+			[]cloudflare.DNSRecord{aRecord("r1", "192.0.2.1"), aRecord("r2", "192.0.2.9")},
+			syncUpdated,
+			[]string{"r1=192.0.2.2", "r2=192.0.2.9"},
+			[]notify.EventType{notify.EventDuplicates, notify.EventUpdateSuccess},
+		},
+		{
+			duplicatesUpdateAll,
+			[]cloudflare.DNSRecord{aRecord("r1", "192.0.2.1"), aRecord("r2", "192.0.2.9")},
+			syncUpdated,
+			[]string{"r1=192.0.2.2", "r2=192.0.2.2"},
+			[]notify.EventType{notify.EventUpdateSuccess},
+		},
+		{
+			// 保留已经指向新 IP 的记录
+			duplicatesReplace,
+			[]cloudflare.DNSRecord{aRecord("r1", "192.0.2.1"), aRecord("r2", "192.0.2.2")},
+			syncUnchanged,
+			[]string{"r2=192.0.2.2"},
+			[]notify.EventType{notify.EventDuplicates},
+		},
+		{
+			duplicatesFail,
+			[]cloudflare.DNSRecord{aRecord("r1", "192.0.2.1"), aRecord("r2", "192.0.2.9")},
+			syncFailed,
+			[]string{"r1=192.0.2.1", "r2=192.0.2.9"},
+			[]notify.EventType{notify.EventDuplicates},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			f := newFakeCloudflare(t, tt.records...)
+			cf := newTestCfDDNS(t, f, Config{Records: []RecordConfig{{Name: "a.example.com", IPType: "4", Duplicates: tt.policy}}})
+			events := recordEvents(cf)
+
+			outcome, _ := cf.syncRecord(context.Background(), cf.Config.Records[0], "4", "192.0.2.2")
+			if outcome != tt.outcome {
+				t.Errorf("outcome = %v, want %v", outcome, tt.outcome)
+			}
+			if got := f.contents(); !slices.Equal(got, tt.want) {
+				t.Errorf("records = %v, want %v", got, tt.want)
+			}
+			if got := events.types(); !slices.Equal(got, tt.events) {
+				t.Errorf("events = %v, want %v", got, tt.events)
+			}
+		})
+	}
+}
+
+func TestDuplicatesFailNotifiesOnce(t *testing.T) {
+	f := newFakeCloudflare(t, aRecord("r1", "192.0.2.1"), aRecord("r2", "192.0.2.9"))
+	cf := newTestCfDDNS(t, f, Config{Records: []RecordConfig{{Name: "a.example.com", IPType: "4", Duplicates: duplicatesFail}}})
+	events := recordEvents(cf)
+	rec := cf.Config.Records[0]
+	ctx := context.Background()
+
+	// 第一次发现只发送 duplicates 通知，不再发送 update_failure
+	if outcome, _ := cf.syncRecord(ctx, rec, "4", "192.0.2.2"); outcome != syncFailed {
+		t.Fatalf("outcome = %v, want failed", outcome)
+	}
+	if got := events.types(); !slices.Equal(got, []notify.EventType{notify.EventDuplicates}) {
+		t.Errorf("first sync events = %v, want [duplicates]", got)
+	}
+
+	// 之后的周期和 IP 变化不再通知
+	cf.syncRecord(ctx, rec, "4", "192.0.2.2")
+	cf.syncRecord(ctx, rec, "4", "192.0.2.3")
+	if got := events.types(); len(got) != 0 {
+		t.Errorf("repeated sync events = %v, want none", got)
+	}
+
+	// 重复记录消失后正常更新，再次出现时重新通知
+	f.remove("r2")
+	if outcome, err := cf.syncRecord(ctx, rec, "4", "192.0.2.3"); outcome != syncUpdated {
+		t.Fatalf("outcome after cleanup = %v, %v, want updated", outcome, err)
+	}
+	events.types()
+	cf.state.forget(stateKey(rec, "4"))
+	f.add(aRecord("r3", "192.0.2.9"))
+	cf.syncRecord(ctx, rec, "4", "192.0.2.3")
+	if got := events.types(); !slices.Equal(got, []notify.EventType{notify.EventDuplicates}) {
+		t.Errorf("events after new duplicate = %v, want [duplicates]", got)
+	}
+}
+
+func TestDuplicatesUpdateFirstNotifiesOnce(t *testing.T) {
+	f := newFakeCloudflare(t, aRecord("r1", "192.0.2.1"), aRecord("r2", "192.0.2.9"))
+	cf := newTestCfDDNS(t, f, Config{Records: []RecordConfig{{Name: "a.example.com", IPType: "4"}}})
+	events := recordEvents(cf)
+	rec := cf.Config.Records[0]
+	key := stateKey(rec, "4")
+	ctx := context.Background()
+
+	cf.syncRecord(ctx, rec, "4", "192.0.2.2")
+	if got := events.types(); !slices.Equal(got, []notify.EventType{notify.EventDuplicates, notify.EventUpdateSuccess}) {
+		t.Errorf("first sync events = %v", got)
+	}
+
+	// 缓存过期后的重新同步和 IP 变化都会重新查询，同一组重复记录不再通知
+	cf.state.forget(key)
+	cf.syncRecord(ctx, rec, "4", "192.0.2.2")
+	cf.state.forget(key)
+	cf.syncRecord(ctx, rec, "4", "192.0.2.3")
+	if got := events.types(); !slices.Equal(got, []notify.EventType{notify.EventUpdateSuccess}) {
+		t.Errorf("later events = %v, want [update_success]", got)
+	}
+	if got := f.contents(); !slices.Equal(got, []string{"r1=192.0.2.3", "r2=192.0.2.9"}) {
+		t.Errorf("records = %v", got)
+	}
+}
+
+func TestDuplicatesUpdateAllUsesCache(t *testing.T) {
+	f := newFakeCloudflare(t, aRecord("r1", "192.0.2.1"), aRecord("r2", "192.0.2.9"))
+	cf := newTestCfDDNS(t, f, Config{Records: []RecordConfig{{Name: "a.example.com", IPType: "4", Duplicates: duplicatesUpdateAll}}})
+	events := recordEvents(cf)
+	rec := cf.Config.Records[0]
+	ctx := context.Background()
+
+	if outcome, err := cf.syncRecord(ctx, rec, "4", "192.0.2.2"); err != nil || outcome != syncUpdated {
+		t.Fatalf("first sync = %v, %v", outcome, err)
+	}
+	if cached, _ := cf.state.get(stateKey(rec, "4")); !slices.Equal(cached.recordIDs(), []string{"r1", "r2"}) {
+		t.Fatalf("cached record IDs = %v, want [r1 r2]", cached.recordIDs())
+	}
+
+	// IP 未变化时不访问 Cloudflare
+	f.resetCounts()
+	if outcome, _ := cf.syncRecord(ctx, rec, "4", "192.0.2.2"); outcome != syncUnchanged || f.count("GET") != 0 {
+		t.Errorf("cached sync = %v with %d GET requests, want unchanged without requests", outcome, f.count("GET"))
+	}
+
+	// IP 变化时直接更新所有缓存的记录
+	if outcome, err := cf.syncRecord(ctx, rec, "4", "192.0.2.3"); err != nil || outcome != syncUpdated {
+		t.Fatalf("update = %v, %v", outcome, err)
+	}
+	if f.count("GET") != 0 || f.count("PATCH") != 2 {
+		t.Errorf("update made %d GET and %d PATCH requests, want 0 and 2", f.count("GET"), f.count("PATCH"))
+	}
+	if got := f.contents(); !slices.Equal(got, []string{"r1=192.0.2.3", "r2=192.0.2.3"}) {
+		t.Errorf("records = %v", got)
+	}
+
+	// 其中一条被删除后重新查询，只更新剩余的记录
+	events.types()
+	f.remove("r2")
+	if outcome, err := cf.syncRecord(ctx, rec, "4", "192.0.2.4"); err != nil || outcome != syncUpdated {
+		t.Fatalf("sync after delete = %v, %v", outcome, err)
+	}
+	if cached, _ := cf.state.get(stateKey(rec, "4")); !slices.Equal(cached.recordIDs(), []string{"r1"}) {
+		t.Errorf("cached record IDs = %v, want [r1]", cached.recordIDs())
+	}
+	if got := events.types(); !slices.Equal(got, []notify.EventType{notify.EventUpdateSuccess}) {
+		t.Errorf("events after delete = %v, want [update_success]", got)
+	}
+}
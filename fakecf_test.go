@@ -0,0 +1,215 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"cfddns/internal/cloudflare"
+	"cfddns/internal/notify"
+)
+
+// fakeZoneID fakeCloudflare 中唯一的 zone
+const fakeZoneID = "z1"
+
+// fakeCloudflare 模拟 Cloudflare DNS 记录接口，记录保存在内存中
+type fakeCloudflare struct {
+	t       *testing.T
+	srv     *httptest.Server
+	mu      sync.Mutex
+	records []cloudflare.DNSRecord
+	nextID  int
+	calls   map[string]int // 按请求方法计数
+}
+
+func newFakeCloudflare(t *testing.T, records ...cloudflare.DNSRecord) *fakeCloudflare {
+	f := &fakeCloudflare{t: t, records: records, nextID: len(records) + 1, calls: make(map[string]int)}
+	f.srv = httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeCloudflare) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls[r.Method]++
+
+	prefix := "/zones/" + fakeZoneID + "/dns_records"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		writeFakeError(w, http.StatusNotFound)
+		return
+	}
+	id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	i := f.index(id)
+
+	switch {
+	case r.Method == http.MethodGet && id == "":
+		var result []cloudflare.DNSRecord
+		for _, rec := range f.records {
+			if name := r.URL.Query().Get("name"); name != "" && rec.Name != name {
+				continue
+			}
+			if typ := r.URL.Query().Get("type"); typ != "" && rec.Type != typ {
+				continue
+			}
+			result = append(result, rec)
+		}
+		writeFakeResult(w, result)
+	case r.Method == http.MethodPost && id == "":
+		var rec cloudflare.DNSRecord
+		json.NewDecoder(r.Body).Decode(&rec)
+		rec.ID = fmt.Sprintf("r%d", f.nextID)
+		f.nextID++
+		f.records = append(f.records, rec)
+		writeFakeResult(w, rec)
+	case i < 0:
+		writeFakeError(w, http.StatusNotFound)
+	case r.Method == http.MethodPatch:
+		var patch cloudflare.DNSRecordPatch
+		json.NewDecoder(r.Body).Decode(&patch)
+		f.records[i] = applyPatch(f.records[i], patch)
+		writeFakeResult(w, f.records[i])
+	case r.Method == http.MethodDelete:
+		f.records = append(f.records[:i], f.records[i+1:]...)
+		writeFakeResult(w, map[string]string{"id": id})
+	default:
+		writeFakeError(w, http.StatusMethodNotAllowed)
+	}
+}
+
+// index 返回记录在列表中的位置，不存在时返回 -1
+func (f *fakeCloudflare) index(id string) int {
+	for i, rec := range f.records {
+		if rec.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// record 返回指定 ID 的记录
+func (f *fakeCloudflare) record(id string) (cloudflare.DNSRecord, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i := f.index(id); i >= 0 {
+		return f.records[i], true
+	}
+	return cloudflare.DNSRecord{}, false
+}
+
+// remove 直接删除记录，模拟在 Cloudflare 面板上手动删除
+func (f *fakeCloudflare) remove(id string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if i := f.index(id); i >= 0 {
+		f.records = append(f.records[:i], f.records[i+1:]...)
+	}
+}
+
+// add 直接添加记录，模拟在 Cloudflare 面板上手动添加
+func (f *fakeCloudflare) add(rec cloudflare.DNSRecord) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.records = append(f.records, rec)
+}
+
+// contents 返回 f 中所有记录的 ID=内容
+func (f *fakeCloudflare) contents() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var out []string
+	for _, r := range f.records {
+		out = append(out, r.ID+"="+r.Content)
+	}
+	return out
+}
+
+// count 返回指定方法的请求次数
+func (f *fakeCloudflare) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+// resetCounts 清空请求计数
+func (f *fakeCloudflare) resetCounts() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = make(map[string]int)
+}
+
+func writeFakeResult(w http.ResponseWriter, result any) {
+	json.NewEncoder(w).Encode(map[string]any{"success": true, "result": result})
+}
+
+func writeFakeError(w http.ResponseWriter, status int) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"success":false,"errors":[{"code":%d,"message":"%s"}]}`, status, http.StatusText(status))
+}
+
+// newTestCfDDNS 创建指向 fakeCloudflare 的实例，config 中未设置的常用项使用测试默认值
+func newTestCfDDNS(t *testing.T, f *fakeCloudflare, config Config) *CfDDNS {
+	if config.StateDir == "" {
+		config.StateDir = t.TempDir()
+	}
+	if config.ResyncInterval == 0 {
+		config.ResyncInterval = 3600
+	}
+	for i := range config.Records {
+		if config.Records[i].ZoneID == "" {
+			config.Records[i].ZoneID = fakeZoneID
+		}
+		if config.Records[i].Duplicates == "" {
+			config.Records[i].Duplicates = duplicatesUpdateFirst
+		}
+	}
+	cf := newCfDDNS(config)
+	cf.useFake(f)
+	return cf
+}
+
+// useFake 让 Cloudflare 请求发往 f，applyConfig 之后需要重新调用
+func (cf *CfDDNS) useFake(f *fakeCloudflare) {
+	cf.api.BaseURL = f.srv.URL
+	cf.api.Retry = &cloudflare.RetryPolicy{MaxAttempts: 1}
+}
+
+// recordedEvents 记录发送的通知，用作测试中的通知渠道
+type recordedEvents struct {
+	mu     sync.Mutex
+	events []notify.Event
+}
+
+func (r *recordedEvents) Name() string { return "test" }
+func (r *recordedEvents) Type() string { return "test" }
+
+func (r *recordedEvents) Send(ctx context.Context, event notify.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+// types 返回已发送通知的事件类型并清空记录
+func (r *recordedEvents) types() []notify.EventType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var types []notify.EventType
+	for _, e := range r.events {
+		types = append(types, e.Type)
+	}
+	r.events = nil
+	return types
+}
+
+// recordEvents 把 cf 的通知渠道替换为 recordedEvents
+func recordEvents(cf *CfDDNS) *recordedEvents {
+	r := &recordedEvents{}
+	cf.notifiers = []notifier{{Notifier: r, config: notify.Config{Name: "test"}}}
+	return r
+}
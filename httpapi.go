@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpAPI 本地 HTTP 状态与控制接口
+// 处理函数只访问 statusTracker、触发通道和受锁保护的 token，不直接读取 CfDDNS.Config
+type httpAPI struct {
+	status  *statusTracker
+	trigger chan<- triggerSource
+
+	mu    sync.RWMutex
+	token string // POST /trigger 使用的 Bearer Token，为空时禁用
+}
+
+// triggerSource 立即同步的触发来源，如 SIGUSR1、POST /trigger
+type triggerSource string
+
+// setToken 更新 api_token，重新加载配置时调用
+func (a *httpAPI) setToken(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = token
+}
+
+// authorized 校验请求的 Bearer Token
+func (a *httpAPI) authorized(r *http.Request) (bool, bool) {
+	a.mu.RLock()
+	token := a.token
+	a.mu.RUnlock()
+	if token == "" {
+		return false, false
+	}
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1, true
+}
+
+func (a *httpAPI) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", a.handleHealthz)
+	mux.HandleFunc("GET /status", a.handleStatus)
+	mux.HandleFunc("POST /trigger", a.handleTrigger)
+	mux.HandleFunc("GET /metrics", a.handleMetrics)
+	return mux
+}
+
+// handleHealthz 进程存活检查
+func (a *httpAPI) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleStatus 返回每条记录的检测 IP、DNS IP、最后检查时间、最后修改时间和最后的错误
+func (a *httpAPI) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"version": Version,
+		"records": a.status.snapshot(),
+	})
+}
+
+// handleTrigger 请求守护进程立即执行一次同步
+func (a *httpAPI) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	ok, enabled := a.authorized(r)
+	switch {
+	case !enabled:
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "api_token is not configured"})
+		return
+	case !ok:
+		w.Header().Set("WWW-Authenticate", `Bearer realm="cfddns"`)
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid or missing bearer token"})
+		return
+	}
+
+	// 已有未处理的触发请求时合并为一次
+	select {
+	case a.trigger <- "POST /trigger":
+	default:
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": "triggered"})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		slog.Debug("Failed to write HTTP response", "error", err)
+	}
+}
+
+// serveAPI 在 addr 上启动 HTTP API，ctx 取消后关闭
+func (cf *CfDDNS) serveAPI(ctx context.Context, addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	srv := &http.Server{
+		Handler:           cf.httpAPI.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	timeout := time.Duration(cf.Config.ShutdownTimeout) * time.Second
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), timeout)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP API stopped", "error", err)
+		}
+	}()
+	slog.Info("HTTP API listening.", "addr", ln.Addr().String())
+	return nil
+}
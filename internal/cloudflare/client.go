@@ -0,0 +1,220 @@
+// Package cloudflare 是 cfddns 使用的 Cloudflare API v4 精简客户端
+package cloudflare
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL Cloudflare API v4 的默认地址
+const DefaultBaseURL = "https://api.cloudflare.com/client/v4"
+
+// Client Cloudflare API 客户端
+type Client struct {
+	BaseURL    string       // 留空则使用 DefaultBaseURL
+	Token      string       // API Token
+	HTTPClient *http.Client // 留空则使用 http.DefaultClient
+	Retry      *RetryPolicy // 留空则使用 DefaultRetryPolicy
+
+	// OnResponse 每次请求（含重试）结束后调用，statusCode 为 0 表示没有收到响应
+	OnResponse func(method string, statusCode int)
+
+	stats clientStats
+}
+
+// APIError Cloudflare 返回的 errors[] / messages[] 中的单条信息
+type APIError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e APIError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Message)
+}
+
+// ResultInfo 分页信息
+type ResultInfo struct {
+	Page       int `json:"page"`
+	PerPage    int `json:"per_page"`
+	Count      int `json:"count"`
+	TotalCount int `json:"total_count"`
+	TotalPages int `json:"total_pages"`
+}
+
+// APIResponse Cloudflare API 的统一响应结构
+type APIResponse[T any] struct {
+	Success    bool        `json:"success"`
+	Errors     []APIError  `json:"errors"`
+	Messages   []APIError  `json:"messages"`
+	Result     T           `json:"result"`
+	ResultInfo *ResultInfo `json:"result_info,omitempty"`
+}
+
+// Error 表示一次失败的 API 调用，包含 HTTP 状态码和 Cloudflare 的 errors[]
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Errors     []APIError
+	RetryAfter time.Duration // 响应中 Retry-After 指定的等待时间，未指定时为 0
+}
+
+func (e *Error) Error() string {
+	msgs := make([]string, 0, len(e.Errors))
+	for _, apiErr := range e.Errors {
+		msgs = append(msgs, apiErr.Error())
+	}
+	detail := "success=false"
+	if len(msgs) > 0 {
+		detail = strings.Join(msgs, "; ")
+	}
+	return fmt.Sprintf("cloudflare: %s %s: HTTP %d: %s", e.Method, e.Path, e.StatusCode, detail)
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+func (c *Client) retryPolicy() RetryPolicy {
+	if c.Retry != nil {
+		return *c.Retry
+	}
+	return DefaultRetryPolicy
+}
+
+func (c *Client) baseURL() string {
+	if c.BaseURL != "" {
+		return strings.TrimRight(c.BaseURL, "/")
+	}
+	return DefaultBaseURL
+}
+
+// do 发送请求并将响应解析到 APIResponse[T]，
+// HTTP 状态码非 2xx 或 success=false 时返回 *Error，临时错误按 Retry 策略重试
+func do[T any](ctx context.Context, c *Client, method, path string, query url.Values, body any) (APIResponse[T], error) {
+	reqURL := c.baseURL() + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	var data []byte
+	if body != nil {
+		var err error
+		data, err = json.Marshal(body)
+		if err != nil {
+			return APIResponse[T]{}, fmt.Errorf("cloudflare: encode request body: %w", err)
+		}
+	}
+
+	policy := c.retryPolicy()
+	for attempt := 1; ; attempt++ {
+		apiResp, statusCode, err := doOnce[T](ctx, c, method, path, reqURL, data)
+		c.stats.record(err)
+		if c.OnResponse != nil {
+			c.OnResponse(method, statusCode)
+		}
+		if err == nil || attempt >= policy.MaxAttempts || !shouldRetry(method, err) {
+			return apiResp, err
+		}
+
+		delay := policy.backoff(attempt)
+		var apiErr *Error
+		if errors.As(err, &apiErr) && apiErr.RetryAfter > 0 {
+			// Retry-After 过长时不在本次请求中等待，交给下一个检测周期
+			if apiErr.RetryAfter > policy.MaxDelay {
+				return apiResp, err
+			}
+			delay = apiErr.RetryAfter
+		}
+
+		slog.Warn("Cloudflare request failed, retrying", "method", method, "path", path,
+			"attempt", attempt, "max_attempts", policy.MaxAttempts, "delay", delay, "error", err)
+		c.stats.retries.Add(1)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return apiResp, err
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry 判断请求是否可以重试
+// POST 不是幂等的，服务端错误或网络错误时可能已经创建成功，只在限流时重试
+func shouldRetry(method string, err error) bool {
+	if !IsRetryable(err) {
+		return false
+	}
+	if method != http.MethodPost {
+		return true
+	}
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests
+}
+
+// doOnce 发送一次请求，同时返回 HTTP 状态码，没有收到响应时为 0
+func doOnce[T any](ctx context.Context, c *Client, method, path, reqURL string, data []byte) (APIResponse[T], int, error) {
+	var apiResp APIResponse[T]
+
+	var reqBody io.Reader
+	if data != nil {
+		reqBody = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reqBody)
+	if err != nil {
+		return apiResp, 0, fmt.Errorf("cloudflare: build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	if data != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return apiResp, 0, fmt.Errorf("cloudflare: %s %s: %w", method, path, err)
+		}
+		return apiResp, 0, fmt.Errorf("cloudflare: %s %s: %w", method, path, &networkError{err: err})
+	}
+	defer resp.Body.Close()
+
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
+	if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return apiResp, resp.StatusCode, &Error{Method: method, Path: path, StatusCode: resp.StatusCode, RetryAfter: retryAfter}
+		}
+		return apiResp, resp.StatusCode, fmt.Errorf("cloudflare: %s %s: decode response: %w", method, path, err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 || !apiResp.Success {
+		return apiResp, resp.StatusCode, &Error{Method: method, Path: path, StatusCode: resp.StatusCode, Errors: apiResp.Errors, RetryAfter: retryAfter}
+	}
+	return apiResp, resp.StatusCode, nil
+}
+
+// IsNotFound 判断错误是否为资源不存在（HTTP 404）
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsForbidden 判断错误是否为无权访问（HTTP 403），如 Token 不再有该 zone 的权限
+func IsForbidden(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusForbidden
+}
@@ -0,0 +1,88 @@
+package cloudflare
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// DNSRecord DNS 记录
+type DNSRecord struct {
+	ID      string   `json:"id,omitempty"`
+	ZoneID  string   `json:"zone_id,omitempty"`
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Content string   `json:"content"`
+	TTL     int      `json:"ttl,omitempty"`
+	Proxied bool     `json:"proxied"`
+	Comment string   `json:"comment,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+}
+
+// DNSRecordPatch 部分更新 DNS 记录时提交的字段，nil/空值的字段不会被修改
+type DNSRecordPatch struct {
+	Content string    `json:"content,omitempty"`
+	TTL     *int      `json:"ttl,omitempty"`
+	Proxied *bool     `json:"proxied,omitempty"`
+	Comment *string   `json:"comment,omitempty"`
+	Tags    *[]string `json:"tags,omitempty"`
+}
+
+// IsEmpty 判断是否没有需要修改的字段
+func (p DNSRecordPatch) IsEmpty() bool {
+	return p.Content == "" && p.TTL == nil && p.Proxied == nil && p.Comment == nil && p.Tags == nil
+}
+
+// ListDNSRecordsParams 查询 DNS 记录的过滤条件，留空的字段不参与过滤
+type ListDNSRecordsParams struct {
+	Name string
+	Type string
+}
+
+func dnsRecordsPath(zoneID string) string {
+	return fmt.Sprintf("/zones/%s/dns_records", url.PathEscape(zoneID))
+}
+
+// ListDNSRecords 列出 zone 下符合条件的 DNS 记录
+func (c *Client) ListDNSRecords(ctx context.Context, zoneID string, params ListDNSRecordsParams) ([]DNSRecord, error) {
+	query := url.Values{}
+	if params.Name != "" {
+		query.Set("name", params.Name)
+	}
+	if params.Type != "" {
+		query.Set("type", params.Type)
+	}
+	resp, err := do[[]DNSRecord](ctx, c, "GET", dnsRecordsPath(zoneID), query, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Result, nil
+}
+
+// CreateDNSRecord 创建 DNS 记录
+func (c *Client) CreateDNSRecord(ctx context.Context, zoneID string, record DNSRecord) (DNSRecord, error) {
+	resp, err := do[DNSRecord](ctx, c, "POST", dnsRecordsPath(zoneID), nil, record)
+	if err != nil {
+		return DNSRecord{}, err
+	}
+	return resp.Result, nil
+}
+
+// PatchDNSRecord 部分更新 DNS 记录（PATCH），只修改 patch 中设置的字段
+func (c *Client) PatchDNSRecord(ctx context.Context, zoneID, recordID string, patch DNSRecordPatch) (DNSRecord, error) {
+	path := dnsRecordsPath(zoneID) + "/" + url.PathEscape(recordID)
+	resp, err := do[DNSRecord](ctx, c, "PATCH", path, nil, patch)
+	if err != nil {
+		return DNSRecord{}, err
+	}
+	return resp.Result, nil
+}
+
+// DeleteDNSRecord 删除 DNS 记录
+func (c *Client) DeleteDNSRecord(ctx context.Context, zoneID, recordID string) error {
+	path := dnsRecordsPath(zoneID) + "/" + url.PathEscape(recordID)
+	_, err := do[struct {
+		ID string `json:"id"`
+	}](ctx, c, "DELETE", path, nil, nil)
+	return err
+}
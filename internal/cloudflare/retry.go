@@ -0,0 +1,138 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// RetryPolicy 请求失败时的重试策略
+// 429、5xx 和网络错误会按指数退避加随机抖动重试，其他错误（认证、参数校验等）直接返回
+type RetryPolicy struct {
+	MaxAttempts int           // 最多尝试次数（含第一次），小于等于 1 表示不重试
+	BaseDelay   time.Duration // 第一次重试前的等待时间，之后每次翻倍
+	MaxDelay    time.Duration // 单次等待的上限，Retry-After 超过该值时放弃重试
+}
+
+// DefaultRetryPolicy Client.Retry 为空时使用的重试策略
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	BaseDelay:   time.Second,
+	MaxDelay:    30 * time.Second,
+}
+
+// backoff 返回第 attempt 次重试（从 1 开始）前的等待时间，在 [d/2, d] 之间随机抖动
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// IsRetryable 判断错误是否为临时错误：限流（429）、服务端错误（5xx）或网络错误
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	var netErr *networkError
+	return errors.As(err, &netErr)
+}
+
+// IsPermanent 判断错误是否为重试也无法解决的错误，如认证失败、参数校验失败
+func IsPermanent(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && !IsRetryable(err)
+}
+
+// networkError 请求未得到 HTTP 响应
+type networkError struct {
+	err error
+}
+
+func (e *networkError) Error() string { return e.err.Error() }
+func (e *networkError) Unwrap() error { return e.err }
+
+// parseRetryAfter 解析 Retry-After 响应头，支持秒数和 HTTP 日期两种格式
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
+
+// Stats 请求计数
+type Stats struct {
+	Requests        int64 // 发出的 HTTP 请求数，含重试
+	Retries         int64 // 重试次数
+	RateLimited     int64 // 收到 429 的次数
+	ServerErrors    int64 // 收到 5xx 的次数
+	NetworkErrors   int64 // 网络错误次数
+	PermanentErrors int64 // 不可重试的失败次数
+}
+
+// Sub 返回 s 相对 prev 的增量，用于统计一个周期内的请求
+func (s Stats) Sub(prev Stats) Stats {
+	return Stats{
+		Requests:        s.Requests - prev.Requests,
+		Retries:         s.Retries - prev.Retries,
+		RateLimited:     s.RateLimited - prev.RateLimited,
+		ServerErrors:    s.ServerErrors - prev.ServerErrors,
+		NetworkErrors:   s.NetworkErrors - prev.NetworkErrors,
+		PermanentErrors: s.PermanentErrors - prev.PermanentErrors,
+	}
+}
+
+// clientStats Client 内部使用的并发安全计数器
+type clientStats struct {
+	requests, retries, rateLimited, serverErrors, networkErrors, permanentErrors atomic.Int64
+}
+
+// Stats 返回客户端创建以来的请求计数
+func (c *Client) Stats() Stats {
+	return Stats{
+		Requests:        c.stats.requests.Load(),
+		Retries:         c.stats.retries.Load(),
+		RateLimited:     c.stats.rateLimited.Load(),
+		ServerErrors:    c.stats.serverErrors.Load(),
+		NetworkErrors:   c.stats.networkErrors.Load(),
+		PermanentErrors: c.stats.permanentErrors.Load(),
+	}
+}
+
+// record 根据单次请求的结果更新计数
+func (s *clientStats) record(err error) {
+	s.requests.Add(1)
+	if err == nil {
+		return
+	}
+	var apiErr *Error
+	var netErr *networkError
+	switch {
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests:
+		s.rateLimited.Add(1)
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 500:
+		s.serverErrors.Add(1)
+	case errors.As(err, &netErr):
+		s.networkErrors.Add(1)
+	case IsPermanent(err):
+		s.permanentErrors.Add(1)
+	}
+}
@@ -0,0 +1,157 @@
+package cloudflare
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 10, BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+	tests := []struct {
+		attempt int
+		max     time.Duration // 抖动后的范围为 [max/2, max]
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{5, 16 * time.Second},
+		{6, 30 * time.Second},  // 32s 超过上限
+		{40, 30 * time.Second}, // 左移溢出
+	}
+	for _, tt := range tests {
+		for range 100 {
+			d := p.backoff(tt.attempt)
+			if d < tt.max/2 || d > tt.max {
+				t.Fatalf("backoff(%d) = %v, want in [%v, %v]", tt.attempt, d, tt.max/2, tt.max)
+			}
+		}
+	}
+
+	if d := (RetryPolicy{}).backoff(1); d != 0 {
+		t.Errorf("zero policy backoff = %v, want 0", d)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		value    string
+		min, max time.Duration
+	}{
+		{"", 0, 0},
+		{"5", 5 * time.Second, 5 * time.Second},
+		{"120", 2 * time.Minute, 2 * time.Minute},
+		{"0", 0, 0},
+		{"-3", 0, 0},
+		{"soon", 0, 0},
+		{time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), 8 * time.Second, 10 * time.Second},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0, 0},
+	}
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.value); got < tt.min || got > tt.max {
+			t.Errorf("parseRetryAfter(%q) = %v, want in [%v, %v]", tt.value, got, tt.min, tt.max)
+		}
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	tests := []struct {
+		method string
+		err    error
+		want   bool
+	}{
+		{http.MethodGet, &Error{StatusCode: http.StatusTooManyRequests}, true},
+		{http.MethodGet, &Error{StatusCode: http.StatusInternalServerError}, true},
+		{http.MethodPatch, &Error{StatusCode: http.StatusBadGateway}, true},
+		{http.MethodDelete, &networkError{err: errors.New("connection reset")}, true},
+		{http.MethodGet, &Error{StatusCode: http.StatusBadRequest}, false},
+		{http.MethodGet, &Error{StatusCode: http.StatusUnauthorized}, false},
+		{http.MethodGet, &Error{StatusCode: http.StatusForbidden}, false},
+		{http.MethodGet, &Error{StatusCode: http.StatusNotFound}, false},
+		{http.MethodGet, context.Canceled, false},
+		{http.MethodGet, fmt.Errorf("wrapped: %w", &Error{StatusCode: http.StatusServiceUnavailable}), true},
+		// POST 只在限流时重试
+		{http.MethodPost, &Error{StatusCode: http.StatusTooManyRequests}, true},
+		{http.MethodPost, &Error{StatusCode: http.StatusInternalServerError}, false},
+		{http.MethodPost, &networkError{err: errors.New("timeout")}, false},
+	}
+	for _, tt := range tests {
+		if got := shouldRetry(tt.method, tt.err); got != tt.want {
+			t.Errorf("shouldRetry(%s, %v) = %v, want %v", tt.method, tt.err, got, tt.want)
+		}
+	}
+}
+
+// flakyServer 前 failures 次请求返回 status，之后返回成功
+func flakyServer(t *testing.T, failures int32, status int, retryAfter string) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			if retryAfter != "" {
+				w.Header().Set("Retry-After", retryAfter)
+			}
+			w.WriteHeader(status)
+			io.WriteString(w, `{"success":false,"errors":[{"code":10000,"message":"error"}]}`)
+			return
+		}
+		io.WriteString(w, `{"success":true,"result":{"id":"r1"}}`)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func TestDoRetry(t *testing.T) {
+	policy := &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 2 * time.Second}
+	tests := []struct {
+		name       string
+		method     string
+		failures   int32
+		status     int
+		retryAfter string
+		wantCalls  int32
+		wantErr    bool
+		minElapsed time.Duration
+	}{
+		{"429 honors Retry-After", http.MethodPatch, 1, http.StatusTooManyRequests, "1", 2, false, time.Second},
+		{"429 Retry-After beyond MaxDelay gives up", http.MethodPatch, 1, http.StatusTooManyRequests, "60", 1, true, 0},
+		{"503 retried until success", http.MethodPatch, 2, http.StatusServiceUnavailable, "", 3, false, 0},
+		{"500 gives up after MaxAttempts", http.MethodPatch, 5, http.StatusInternalServerError, "", 3, true, 0},
+		{"400 not retried", http.MethodPatch, 1, http.StatusBadRequest, "", 1, true, 0},
+		{"POST 500 not retried", http.MethodPost, 1, http.StatusInternalServerError, "", 1, true, 0},
+		{"POST 429 retried", http.MethodPost, 1, http.StatusTooManyRequests, "", 2, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, calls := flakyServer(t, tt.failures, tt.status, tt.retryAfter)
+			c := &Client{BaseURL: srv.URL, Token: "t", Retry: policy}
+
+			start := time.Now()
+			var err error
+			if tt.method == http.MethodPost {
+				_, err = c.CreateDNSRecord(context.Background(), "z", DNSRecord{Name: "a.example.com", Type: "A", Content: "192.0.2.1"})
+			} else {
+				_, err = c.PatchDNSRecord(context.Background(), "z", "r1", DNSRecordPatch{})
+			}
+			elapsed := time.Since(start)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			if elapsed < tt.minElapsed {
+				t.Errorf("elapsed = %v, want at least %v", elapsed, tt.minElapsed)
+			}
+			if stats := c.Stats(); stats.Requests != int64(tt.wantCalls) || stats.Retries != int64(tt.wantCalls-1) {
+				t.Errorf("stats = %+v", stats)
+			}
+		})
+	}
+}
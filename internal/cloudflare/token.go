@@ -0,0 +1,19 @@
+package cloudflare
+
+import "context"
+
+// TokenVerification /user/tokens/verify 的返回结果
+type TokenVerification struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"` // active、disabled、expired
+	ExpiresOn string `json:"expires_on,omitempty"`
+}
+
+// VerifyToken 校验当前 API Token 是否有效
+func (c *Client) VerifyToken(ctx context.Context) (TokenVerification, error) {
+	resp, err := do[TokenVerification](ctx, c, "GET", "/user/tokens/verify", nil, nil)
+	if err != nil {
+		return TokenVerification{}, err
+	}
+	return resp.Result, nil
+}
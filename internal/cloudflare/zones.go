@@ -0,0 +1,35 @@
+package cloudflare
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// zonesPerPage 列出 zone 时每页的数量（Cloudflare 允许的最大值）
+const zonesPerPage = 50
+
+// Zone Cloudflare 上的域名（zone）
+type Zone struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"` // active、pending 等
+}
+
+// ListZones 列出当前 API Token 可以访问的所有 zone，自动处理分页
+func (c *Client) ListZones(ctx context.Context) ([]Zone, error) {
+	var zones []Zone
+	for page := 1; ; page++ {
+		query := url.Values{}
+		query.Set("page", strconv.Itoa(page))
+		query.Set("per_page", strconv.Itoa(zonesPerPage))
+		resp, err := do[[]Zone](ctx, c, "GET", "/zones", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, resp.Result...)
+		if resp.ResultInfo == nil || page >= resp.ResultInfo.TotalPages || len(resp.Result) == 0 {
+			return zones, nil
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// defaultBarkServer Bark 的默认服务器
+const defaultBarkServer = "https://api.day.app"
+
+// BarkConfig [notifiers.bark]
+type BarkConfig struct {
+	Server        string `toml:"server"` // 自建服务器地址，留空使用 https://api.day.app
+	DeviceKey     string `toml:"device_key" secret:"true"`
+	DeviceKeyFile string `toml:"device_key_file"`
+	Group         string `toml:"group"` // 通知分组
+	Sound         string `toml:"sound"` // 提示音，如 alarm
+	Level         string `toml:"level"` // 中断级别：active、timeSensitive、passive、critical
+	Icon          string `toml:"icon"`  // 图标地址
+}
+
+func (c *BarkConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "device_key", c.DeviceKey)
+	problems = validURL(problems, "server", c.Server)
+	problems = validURL(problems, "icon", c.Icon)
+	if !slices.Contains([]string{"", "active", "timeSensitive", "passive", "critical"}, c.Level) {
+		problems = append(problems, Problem{"level", fmt.Sprintf(`invalid value %q, must be "active", "timeSensitive", "passive" or "critical"`, c.Level)})
+	}
+	return problems
+}
+
+func (c *BarkConfig) build(name string, client *http.Client) (Notifier, error) {
+	return &bark{name: name, config: *c, client: client}, nil
+}
+
+// bark 通过 Bark 推送到 iOS 设备
+type bark struct {
+	name   string
+	config BarkConfig
+	client *http.Client
+}
+
+func (b *bark) Name() string { return b.name }
+func (b *bark) Type() string { return "bark" }
+
+func (b *bark) Send(ctx context.Context, event Event) error {
+	body := map[string]any{
+		"device_key": b.config.DeviceKey,
+		"title":      title(event),
+		"body":       event.Message,
+	}
+	for key, value := range map[string]string{"group": b.config.Group, "sound": b.config.Sound, "level": b.config.Level, "icon": b.config.Icon} {
+		if value != "" {
+			body[key] = value
+		}
+	}
+
+	url := strings.TrimRight(withDefault(b.config.Server, defaultBarkServer), "/") + "/push"
+	respBody, err := postJSON(ctx, b.client, url, body, nil)
+	if err != nil {
+		return err
+	}
+	return checkResult(respBody, 200)
+}
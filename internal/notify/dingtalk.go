@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DingTalkConfig [notifiers.dingtalk]，钉钉群自定义机器人
+type DingTalkConfig struct {
+	// 机器人的 Webhook 地址，如 https://oapi.dingtalk.com/robot/send?access_token=xxx
+	WebhookURL     string `toml:"webhook_url" secret:"true"`
+	WebhookURLFile string `toml:"webhook_url_file"`
+	// 安全设置为“加签”时的密钥，以 SEC 开头，留空不签名
+	Secret     string   `toml:"secret" secret:"true"`
+	SecretFile string   `toml:"secret_file"`
+	AtMobiles  []string `toml:"at_mobiles"` // 需要 @ 的成员手机号
+	AtAll      bool     `toml:"at_all"`     // 是否 @ 所有人
+}
+
+func (c *DingTalkConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "webhook_url", c.WebhookURL)
+	problems = validURL(problems, "webhook_url", c.WebhookURL)
+	return problems
+}
+
+func (c *DingTalkConfig) build(name string, client *http.Client) (Notifier, error) {
+	return &dingTalk{name: name, config: *c, client: client}, nil
+}
+
+// dingTalk 通过钉钉群机器人发送文本消息
+type dingTalk struct {
+	name   string
+	config DingTalkConfig
+	client *http.Client
+}
+
+func (d *dingTalk) Name() string { return d.name }
+func (d *dingTalk) Type() string { return "dingtalk" }
+
+// url 返回发送地址，配置了密钥时附加 timestamp 和 sign 参数
+func (d *dingTalk) url(now time.Time) (string, error) {
+	if d.config.Secret == "" {
+		return d.config.WebhookURL, nil
+	}
+	u, err := url.Parse(d.config.WebhookURL)
+	if err != nil {
+		return "", err
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	sign := hmacSHA256([]byte(d.config.Secret), []byte(timestamp+"\n"+d.config.Secret))
+	query := u.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", base64.StdEncoding.EncodeToString(sign))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+func (d *dingTalk) Send(ctx context.Context, event Event) error {
+	url, err := d.url(time.Now())
+	if err != nil {
+		return err
+	}
+
+	// 被 @ 的手机号需要出现在消息内容中才会高亮
+	content := event.Message
+	if len(d.config.AtMobiles) > 0 {
+		content += "\n@" + strings.Join(d.config.AtMobiles, " @")
+	}
+	respBody, err := postJSON(ctx, d.client, url, map[string]any{
+		"msgtype": "text",
+		"text":    map[string]any{"content": content},
+		"at": map[string]any{
+			"atMobiles": d.config.AtMobiles,
+			"isAtAll":   d.config.AtAll,
+		},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return checkResult(respBody, 0)
+}
@@ -0,0 +1,79 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Discord Embed 的颜色
+const (
+	discordColorSuccess = 0x2ecc71
+	discordColorFailure = 0xe74c3c
+	discordColorInfo    = 0x3498db
+)
+
+// DiscordConfig [notifiers.discord]，Discord 频道 Webhook
+type DiscordConfig struct {
+	// Webhook 地址，如 https://discord.com/api/webhooks/123/xxx
+	WebhookURL     string `toml:"webhook_url" secret:"true"`
+	WebhookURLFile string `toml:"webhook_url_file"`
+	Username       string `toml:"username"`   // 覆盖显示名称
+	AvatarURL      string `toml:"avatar_url"` // 覆盖头像
+}
+
+func (c *DiscordConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "webhook_url", c.WebhookURL)
+	problems = validURL(problems, "webhook_url", c.WebhookURL)
+	problems = validURL(problems, "avatar_url", c.AvatarURL)
+	return problems
+}
+
+func (c *DiscordConfig) build(name string, client *http.Client) (Notifier, error) {
+	return &discord{name: name, config: *c, client: client}, nil
+}
+
+// discord 通过频道 Webhook 发送 Embed 消息
+type discord struct {
+	name   string
+	config DiscordConfig
+	client *http.Client
+}
+
+func (d *discord) Name() string { return d.name }
+func (d *discord) Type() string { return "discord" }
+
+func (d *discord) Send(ctx context.Context, event Event) error {
+	color := discordColorInfo
+	switch {
+	case event.Success:
+		color = discordColorSuccess
+	case event.failed():
+		color = discordColorFailure
+	}
+	var fields []map[string]any
+	for _, f := range eventFields(event) {
+		fields = append(fields, map[string]any{"name": f.name, "value": f.value, "inline": true})
+	}
+	embed := map[string]any{
+		"title":       title(event),
+		"description": event.Message,
+		"color":       color,
+		"timestamp":   event.Time.Format(time.RFC3339),
+		"footer":      map[string]any{"text": event.Hostname},
+	}
+	if len(fields) > 0 {
+		embed["fields"] = fields
+	}
+
+	body := map[string]any{"embeds": []map[string]any{embed}}
+	if d.config.Username != "" {
+		body["username"] = d.config.Username
+	}
+	if d.config.AvatarURL != "" {
+		body["avatar_url"] = d.config.AvatarURL
+	}
+	_, err := postJSON(ctx, d.client, d.config.WebhookURL, body, nil)
+	return err
+}
@@ -0,0 +1,277 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// 邮件加密方式
+const (
+	emailTLSStartTLS = "starttls" // 明文连接后升级为 TLS（默认，端口 587）
+	emailTLSImplicit = "tls"      // 直接使用 TLS 连接（端口 465）
+	emailTLSNone     = "none"     // 不加密（端口 25），只能在本机或可信网络中使用
+)
+
+// 默认的邮件主题和正文模板
+const (
+	defaultEmailSubject = "[CfDDNS] {{.Type}} on {{.Hostname}}"
+	defaultEmailBody    = "{{.Message}}\n\nHost: {{.Hostname}}\nTime: {{.Time.Format \"2006-01-02 15:04:05 MST\"}}\n"
+)
+
+// EmailConfig [notifiers.email]
+type EmailConfig struct {
+	Host         string   `toml:"host"`
+	Port         int      `toml:"port"` // 留空时 starttls 使用 587，tls 使用 465，none 使用 25
+	TLS          string   `toml:"tls"`  // starttls（默认）、tls 或 none
+	Auth         string   `toml:"auth"` // plain（默认）或 login，未设置 username 时不认证
+	Username     string   `toml:"username"`
+	Password     string   `toml:"password" secret:"true"`
+	PasswordFile string   `toml:"password_file"`
+	From         string   `toml:"from"`
+	To           []string `toml:"to"`
+	Subject      string   `toml:"subject"` // 主题模板（text/template），数据为 Event
+	Body         string   `toml:"body"`    // 正文模板（text/template），数据为 Event
+}
+
+func (c *EmailConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "host", c.Host)
+	if c.Port < 0 || c.Port > 65535 {
+		problems = append(problems, Problem{"port", fmt.Sprintf("invalid port %d", c.Port)})
+	}
+	if !slices.Contains([]string{"", emailTLSStartTLS, emailTLSImplicit, emailTLSNone}, c.TLS) {
+		problems = append(problems, Problem{"tls", fmt.Sprintf(`invalid value %q, must be "starttls", "tls" or "none"`, c.TLS)})
+	}
+	if !slices.Contains([]string{"", "plain", "login"}, c.Auth) {
+		problems = append(problems, Problem{"auth", fmt.Sprintf(`invalid value %q, must be "plain" or "login"`, c.Auth)})
+	}
+	if c.Username != "" {
+		problems = required(problems, "password", c.Password)
+	}
+
+	problems = required(problems, "from", c.From)
+	if c.From != "" {
+		if _, err := mail.ParseAddress(c.From); err != nil {
+			problems = append(problems, Problem{"from", fmt.Sprintf("invalid address %q: %v", c.From, err)})
+		}
+	}
+	if len(c.To) == 0 {
+		problems = append(problems, Problem{"to", "at least one recipient is required"})
+	}
+	for _, to := range c.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			problems = append(problems, Problem{"to", fmt.Sprintf("invalid address %q: %v", to, err)})
+		}
+	}
+
+	problems = checkTemplate(problems, "subject", c.Subject)
+	problems = checkTemplate(problems, "body", c.Body)
+	return problems
+}
+
+func (c *EmailConfig) build(name string, _ *http.Client) (Notifier, error) {
+	e := &email{name: name, config: *c}
+	var err error
+	if e.subject, err = parseTemplate("subject", withDefault(c.Subject, defaultEmailSubject)); err != nil {
+		return nil, err
+	}
+	if e.body, err = parseTemplate("body", withDefault(c.Body, defaultEmailBody)); err != nil {
+		return nil, err
+	}
+	if e.config.TLS == "" {
+		e.config.TLS = emailTLSStartTLS
+	}
+	if e.config.Port == 0 {
+		switch e.config.TLS {
+		case emailTLSImplicit:
+			e.config.Port = 465
+		case emailTLSNone:
+			e.config.Port = 25
+		default:
+			e.config.Port = 587
+		}
+	}
+	return e, nil
+}
+
+// email 通过 SMTP 发送邮件
+type email struct {
+	name    string
+	config  EmailConfig
+	subject *template.Template
+	body    *template.Template
+}
+
+func (e *email) Name() string { return e.name }
+func (e *email) Type() string { return "email" }
+
+func (e *email) Send(ctx context.Context, event Event) error {
+	subject, err := render(e.subject, event)
+	if err != nil {
+		return fmt.Errorf("subject: %w", err)
+	}
+	body, err := render(e.body, event)
+	if err != nil {
+		return fmt.Errorf("body: %w", err)
+	}
+	from, err := mail.ParseAddress(e.config.From)
+	if err != nil {
+		return err
+	}
+	var to []*mail.Address
+	for _, addr := range e.config.To {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return err
+		}
+		to = append(to, a)
+	}
+
+	client, err := e.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if e.config.TLS == emailTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("server does not support STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: e.config.Host}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if e.config.Username != "" {
+		auth := smtp.PlainAuth("", e.config.Username, e.config.Password, e.config.Host)
+		if e.config.Auth == "login" {
+			auth = &loginAuth{username: e.config.Username, password: e.config.Password, host: e.config.Host}
+		}
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	if err := client.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, addr := range to {
+		if err := client.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("rcpt %s: %w", addr.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buildMessage(from, to, string(subject), body, event.Time)); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// dial 连接 SMTP 服务器，连接的读写截止时间跟随 ctx
+func (e *email) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(e.config.Host, strconv.Itoa(e.config.Port))
+	var conn net.Conn
+	var err error
+	if e.config.TLS == emailTLSImplicit {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: e.config.Host}}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	client, err := smtp.NewClient(conn, e.config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+// buildMessage 构造纯文本邮件，主题和收发件人名称按 RFC 2047 编码，换行统一为 CRLF
+func buildMessage(from *mail.Address, to []*mail.Address, subject string, body []byte, date time.Time) []byte {
+	if date.IsZero() {
+		date = time.Now()
+	}
+	recipients := make([]string, 0, len(to))
+	for _, addr := range to {
+		recipients = append(recipients, addr.String())
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(recipients, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject)))
+	fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	text := strings.ReplaceAll(string(body), "\r\n", "\n")
+	buf.WriteString(strings.ReplaceAll(text, "\n", "\r\n"))
+	return buf.Bytes()
+}
+
+// loginAuth 实现 AUTH LOGIN，与 smtp.PlainAuth 一样只在 TLS 连接或本机上发送密码
+type loginAuth struct {
+	username, password, host string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch prompt := strings.ToLower(strings.TrimSpace(string(fromServer))); {
+	case strings.HasPrefix(prompt, "username"):
+		return []byte(a.username), nil
+	case strings.HasPrefix(prompt, "password"):
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge %q", fromServer)
+	}
+}
+
+// isLocalhost 判断是否为本机地址
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// withDefault value 为空时返回 def
+func withDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// FeishuConfig [notifiers.feishu]，飞书（Lark）群自定义机器人
+type FeishuConfig struct {
+	// 机器人的 Webhook 地址，如 https://open.feishu.cn/open-apis/bot/v2/hook/xxx
+	WebhookURL     string `toml:"webhook_url" secret:"true"`
+	WebhookURLFile string `toml:"webhook_url_file"`
+	// 安全设置为“签名校验”时的密钥，留空不签名
+	Secret     string `toml:"secret" secret:"true"`
+	SecretFile string `toml:"secret_file"`
+}
+
+func (c *FeishuConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "webhook_url", c.WebhookURL)
+	problems = validURL(problems, "webhook_url", c.WebhookURL)
+	return problems
+}
+
+func (c *FeishuConfig) build(name string, client *http.Client) (Notifier, error) {
+	return &feishu{name: name, config: *c, client: client}, nil
+}
+
+// feishu 通过飞书群机器人发送文本消息
+type feishu struct {
+	name   string
+	config FeishuConfig
+	client *http.Client
+}
+
+func (f *feishu) Name() string { return f.name }
+func (f *feishu) Type() string { return "feishu" }
+
+func (f *feishu) Send(ctx context.Context, event Event) error {
+	body := map[string]any{
+		"msg_type": "text",
+		"content":  map[string]any{"text": event.Message},
+	}
+	if f.config.Secret != "" {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		body["timestamp"] = timestamp
+		body["sign"] = feishuSign(timestamp, f.config.Secret)
+	}
+
+	respBody, err := postJSON(ctx, f.client, f.config.WebhookURL, body, nil)
+	if err != nil {
+		return err
+	}
+	return checkResult(respBody, 0)
+}
+
+// feishuSign 返回飞书签名校验的 sign，飞书以 timestamp + "\n" + secret 作为密钥，对空消息计算 HMAC-SHA256
+func feishuSign(timestamp, secret string) string {
+	return base64.StdEncoding.EncodeToString(hmacSHA256([]byte(timestamp+"\n"+secret), nil))
+}
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultGotifyPriority Gotify 消息的默认优先级
+const defaultGotifyPriority = 5
+
+// GotifyConfig [notifiers.gotify]
+type GotifyConfig struct {
+	Server    string `toml:"server"` // 如 https://gotify.example.com
+	Token     string `toml:"token" secret:"true"`
+	TokenFile string `toml:"token_file"`
+	Priority  *int   `toml:"priority"` // 0-10，留空为 5
+}
+
+func (c *GotifyConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "server", c.Server)
+	problems = validURL(problems, "server", c.Server)
+	problems = required(problems, "token", c.Token)
+	if c.Priority != nil && (*c.Priority < 0 || *c.Priority > 10) {
+		problems = append(problems, Problem{"priority", fmt.Sprintf("must be between 0 and 10, got %d", *c.Priority)})
+	}
+	return problems
+}
+
+func (c *GotifyConfig) build(name string, client *http.Client) (Notifier, error) {
+	return &gotify{name: name, config: *c, client: client}, nil
+}
+
+// gotify 通过应用 Token 向 Gotify 服务器发送消息
+type gotify struct {
+	name   string
+	config GotifyConfig
+	client *http.Client
+}
+
+func (g *gotify) Name() string { return g.name }
+func (g *gotify) Type() string { return "gotify" }
+
+func (g *gotify) Send(ctx context.Context, event Event) error {
+	priority := defaultGotifyPriority
+	if g.config.Priority != nil {
+		priority = *g.config.Priority
+	}
+	_, err := postJSON(ctx, g.client, strings.TrimRight(g.config.Server, "/")+"/message", map[string]any{
+		"title":    title(event),
+		"message":  event.Message,
+		"priority": priority,
+	}, map[string]string{"X-Gotify-Key": g.config.Token})
+	return err
+}
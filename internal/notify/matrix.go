@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// matrixTxnID 生成事务 ID，避免同一进程内重复
+var matrixTxnID atomic.Uint64
+
+// MatrixConfig [notifiers.matrix]
+type MatrixConfig struct {
+	Homeserver      string `toml:"homeserver"` // 如 https://matrix.example.com
+	AccessToken     string `toml:"access_token" secret:"true"`
+	AccessTokenFile string `toml:"access_token_file"`
+	RoomID          string `toml:"room_id"` // 房间 ID，如 !abc:example.com，机器人需要已加入该房间
+	MsgType         string `toml:"msgtype"` // m.notice（默认）或 m.text
+}
+
+func (c *MatrixConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "homeserver", c.Homeserver)
+	problems = validURL(problems, "homeserver", c.Homeserver)
+	problems = required(problems, "access_token", c.AccessToken)
+	problems = required(problems, "room_id", c.RoomID)
+	if c.RoomID != "" && !strings.HasPrefix(c.RoomID, "!") {
+		problems = append(problems, Problem{"room_id", fmt.Sprintf("invalid room ID %q, must start with !", c.RoomID)})
+	}
+	if !slices.Contains([]string{"", "m.notice", "m.text"}, c.MsgType) {
+		problems = append(problems, Problem{"msgtype", fmt.Sprintf(`invalid value %q, must be "m.notice" or "m.text"`, c.MsgType)})
+	}
+	return problems
+}
+
+func (c *MatrixConfig) build(name string, client *http.Client) (Notifier, error) {
+	return &matrix{name: name, config: *c, client: client}, nil
+}
+
+// matrix 通过 Client-Server API 向房间发送消息
+type matrix struct {
+	name   string
+	config MatrixConfig
+	client *http.Client
+}
+
+func (m *matrix) Name() string { return m.name }
+func (m *matrix) Type() string { return "matrix" }
+
+func (m *matrix) Send(ctx context.Context, event Event) error {
+	txnID := fmt.Sprintf("cfddns-%d-%d", time.Now().UnixNano(), matrixTxnID.Add(1))
+	endpoint := fmt.Sprintf("%s/_matrix/client/v3/rooms/%s/send/m.room.message/%s",
+		strings.TrimRight(m.config.Homeserver, "/"), url.PathEscape(m.config.RoomID), txnID)
+
+	data, err := json.Marshal(map[string]any{
+		"msgtype": withDefault(m.config.MsgType, "m.notice"),
+		"body":    event.Message,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+m.config.AccessToken)
+	_, err = doRequest(m.client, req)
+	return err
+}
@@ -0,0 +1,330 @@
+// Package notify 实现 cfddns 的通知渠道
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// EventType 通知事件类型
+type EventType string
+
+const (
+	EventUpdateSuccess EventType = "update_success" // 记录创建或更新成功
+	EventUpdateFailure EventType = "update_failure" // 记录创建或更新失败
+	EventIPFailure     EventType = "ip_failure"     // 获取公网 IP 失败
+	EventDuplicates    EventType = "duplicates"     // 发现同名同类型的多条记录
+	EventStartup       EventType = "startup"        // 守护进程启动
+	EventShutdown      EventType = "shutdown"       // 守护进程退出
+	EventTest          EventType = "test"           // 测试消息，不受 events 过滤
+)
+
+// EventTypes 可以在 events 中配置的全部事件
+var EventTypes = []EventType{EventUpdateSuccess, EventUpdateFailure, EventIPFailure, EventDuplicates, EventStartup, EventShutdown}
+
+// Event 一次通知的内容
+type Event struct {
+	Type     EventType `json:"type"`
+	Time     time.Time `json:"time"`
+	Hostname string    `json:"hostname"`
+	Message  string    `json:"message"` // 可直接发送的文本
+	Record   string    `json:"record,omitempty"`
+	IPType   string    `json:"ip_type,omitempty"`
+	OldIP    string    `json:"old_ip,omitempty"`
+	NewIP    string    `json:"new_ip,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Success  bool      `json:"success"` // update_success 以及测试消息为 true
+	DryRun   bool      `json:"dry_run,omitempty"`
+}
+
+// sampleEvent 校验模板时使用的示例事件
+var sampleEvent = Event{
+	Type:     EventUpdateSuccess,
+	Hostname: "localhost",
+	Message:  "IPv4 DNS record for home.example.com updated from 192.0.2.1 to 192.0.2.2 successfully.",
+	Record:   "home.example.com",
+	IPType:   "4",
+	OldIP:    "192.0.2.1",
+	NewIP:    "192.0.2.2",
+	Success:  true,
+}
+
+// Notifier 通知渠道
+type Notifier interface {
+	// Name 返回配置中的名称
+	Name() string
+	// Type 返回渠道类型，如 telegram
+	Type() string
+	// Send 发送一条通知
+	Send(ctx context.Context, event Event) error
+}
+
+// Config 单个通知渠道的配置，对应 [[notifiers]]
+// 渠道自身的设置写在与 type 同名的子表中，如 [notifiers.telegram]
+type Config struct {
+	Name   string   `toml:"name"`
+	Type   string   `toml:"type"`
+	Events []string `toml:"events"` // 需要通知的事件，留空表示全部
+
+	Telegram *TelegramConfig `toml:"telegram"`
+	Webhook  *WebhookConfig  `toml:"webhook"`
+	Email    *EmailConfig    `toml:"email"`
+
+	ServerChan *ServerChanConfig `toml:"serverchan"`
+	PushPlus   *PushPlusConfig   `toml:"pushplus"`
+	WeCom      *WeComConfig      `toml:"wecom"`
+	DingTalk   *DingTalkConfig   `toml:"dingtalk"`
+	Feishu     *FeishuConfig     `toml:"feishu"`
+	Bark       *BarkConfig       `toml:"bark"`
+
+	Slack   *SlackConfig   `toml:"slack"`
+	Discord *DiscordConfig `toml:"discord"`
+	Matrix  *MatrixConfig  `toml:"matrix"`
+	Gotify  *GotifyConfig  `toml:"gotify"`
+	Ntfy    *NtfyConfig    `toml:"ntfy"`
+}
+
+// Problem 配置问题，Field 为相对 [[notifiers]] 中该项的配置项，如 telegram.token
+type Problem struct {
+	Field   string
+	Message string
+}
+
+// settings 渠道子表需要实现的接口
+type settings interface {
+	validate() []Problem
+	build(name string, client *http.Client) (Notifier, error)
+}
+
+// settings 返回 type 对应的子表，子表缺失或类型未知时返回错误
+func (c Config) settings() (settings, error) {
+	var s settings
+	switch c.Type {
+	case "telegram":
+		s = orNil(c.Telegram)
+	case "webhook":
+		s = orNil(c.Webhook)
+	case "email":
+		s = orNil(c.Email)
+	case "serverchan":
+		s = orNil(c.ServerChan)
+	case "pushplus":
+		s = orNil(c.PushPlus)
+	case "wecom":
+		s = orNil(c.WeCom)
+	case "dingtalk":
+		s = orNil(c.DingTalk)
+	case "feishu":
+		s = orNil(c.Feishu)
+	case "bark":
+		s = orNil(c.Bark)
+	case "slack":
+		s = orNil(c.Slack)
+	case "discord":
+		s = orNil(c.Discord)
+	case "matrix":
+		s = orNil(c.Matrix)
+	case "gotify":
+		s = orNil(c.Gotify)
+	case "ntfy":
+		s = orNil(c.Ntfy)
+	default:
+		return nil, fmt.Errorf("unknown notifier type %q", c.Type)
+	}
+	if s == nil {
+		return nil, fmt.Errorf("missing [notifiers.%s] settings", c.Type)
+	}
+	return s, nil
+}
+
+// orNil 子表未配置时返回值为 nil 的接口，而不是包含 nil 指针的接口
+func orNil[T any, P interface {
+	*T
+	settings
+}](p P) settings {
+	if p == nil {
+		return nil
+	}
+	return p
+}
+
+// Validate 校验配置，不检查 name 是否重复
+func (c Config) Validate() []Problem {
+	var problems []Problem
+	if c.Name == "" {
+		problems = append(problems, Problem{"name", "is required"})
+	}
+	for _, e := range c.Events {
+		if !slices.Contains(EventTypes, EventType(e)) {
+			problems = append(problems, Problem{"events", fmt.Sprintf("unknown event %q", e)})
+		}
+	}
+	s, err := c.settings()
+	if err != nil {
+		return append(problems, Problem{"type", err.Error()})
+	}
+	for _, p := range s.validate() {
+		p.Field = c.Type + "." + p.Field
+		problems = append(problems, p)
+	}
+	return problems
+}
+
+// Wants 判断是否需要通知该事件
+func (c Config) Wants(t EventType) bool {
+	return t == EventTest || len(c.Events) == 0 || slices.Contains(c.Events, string(t))
+}
+
+// New 根据配置创建通知渠道，client 用于发送 HTTP 请求
+func New(c Config, client *http.Client) (Notifier, error) {
+	s, err := c.settings()
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return s.build(c.Name, client)
+}
+
+// postJSON 以 JSON 格式 POST body，HTTP 状态码非 2xx 时返回包含响应内容的错误
+func postJSON(ctx context.Context, client *http.Client, url string, body any, headers map[string]string) ([]byte, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return doRequest(client, req)
+}
+
+// doRequest 发送请求并读取响应，HTTP 状态码非 2xx 时返回错误
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return respBody, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return respBody, nil
+}
+
+// eventTitles 各事件的标题，用于需要单独标题的渠道
+var eventTitles = map[EventType]string{
+	EventUpdateSuccess: "DNS record updated",
+	EventUpdateFailure: "DNS record update failed",
+	EventIPFailure:     "IP detection failed",
+	EventDuplicates:    "Duplicate DNS records",
+	EventStartup:       "Started",
+	EventShutdown:      "Shutting down",
+	EventTest:          "Test message",
+}
+
+// title 返回事件的标题，如 CfDDNS: DNS record updated
+func title(event Event) string {
+	t := "CfDDNS: " + eventTitles[event.Type]
+	if event.DryRun {
+		t = "[DRY RUN] " + t
+	}
+	return t
+}
+
+// failed 判断是否为失败事件
+func (e Event) failed() bool {
+	return e.Type == EventUpdateFailure || e.Type == EventIPFailure
+}
+
+// field 事件的一项详细信息
+type field struct {
+	name, value string
+}
+
+// eventFields 返回事件中非空的记录、IP 和错误信息，用于支持结构化字段的渠道
+func eventFields(e Event) []field {
+	var fields []field
+	for _, f := range []field{{"Record", e.Record}, {"Old IP", e.OldIP}, {"New IP", e.NewIP}, {"Error", e.Error}} {
+		if f.value != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// apiResult 国内推送服务常见的 JSON 响应格式
+type apiResult struct {
+	Code    *int   `json:"code"`
+	ErrCode *int   `json:"errcode"`
+	Msg     string `json:"msg"`
+	Message string `json:"message"`
+	ErrMsg  string `json:"errmsg"`
+}
+
+// checkResult 检查响应中的 code/errcode 是否为 okCode，响应不是 JSON 或不含 code 时视为成功
+func checkResult(body []byte, okCode int) error {
+	var result apiResult
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil
+	}
+	code := result.Code
+	if code == nil {
+		code = result.ErrCode
+	}
+	if code == nil || *code == okCode {
+		return nil
+	}
+	msg := result.Msg
+	if msg == "" {
+		msg = result.Message
+	}
+	if msg == "" {
+		msg = result.ErrMsg
+	}
+	return fmt.Errorf("code %d: %s", *code, msg)
+}
+
+// hmacSHA256 返回 HMAC-SHA256 摘要
+func hmacSHA256(key, message []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+// required 校验必填项
+func required(problems []Problem, field, value string) []Problem {
+	if value == "" {
+		return append(problems, Problem{field, "is required"})
+	}
+	return problems
+}
+
+// validURL 校验 http/https 地址，为空时不校验
+func validURL(problems []Problem, field, value string) []Problem {
+	if value == "" {
+		return problems
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return append(problems, Problem{field, fmt.Sprintf("invalid URL %q, must be an http:// or https:// address", value)})
+	}
+	return problems
+}
@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultNtfyServer ntfy 的默认服务器
+const defaultNtfyServer = "https://ntfy.sh"
+
+// ntfyPriorities ntfy 优先级名称对应的数值
+var ntfyPriorities = map[string]int{
+	"min": 1, "1": 1,
+	"low": 2, "2": 2,
+	"default": 3, "3": 3,
+	"high": 4, "4": 4,
+	"max": 5, "urgent": 5, "5": 5,
+}
+
+// NtfyConfig [notifiers.ntfy]
+type NtfyConfig struct {
+	Server       string   `toml:"server"` // 自建服务器地址，留空使用 https://ntfy.sh
+	Topic        string   `toml:"topic"`
+	Token        string   `toml:"token" secret:"true"` // 访问令牌，与 username/password 二选一
+	TokenFile    string   `toml:"token_file"`
+	Username     string   `toml:"username"`
+	Password     string   `toml:"password" secret:"true"`
+	PasswordFile string   `toml:"password_file"`
+	Priority     string   `toml:"priority"` // min、low、default、high、max 或 1-5，留空为 default
+	Tags         []string `toml:"tags"`     // 标签，与 emoji 短代码同名的标签显示为图标，如 globe_with_meridians
+}
+
+func (c *NtfyConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "topic", c.Topic)
+	problems = validURL(problems, "server", c.Server)
+	if _, ok := ntfyPriorities[c.Priority]; c.Priority != "" && !ok {
+		problems = append(problems, Problem{"priority", fmt.Sprintf(`invalid value %q, must be min, low, default, high, max or 1-5`, c.Priority)})
+	}
+	if c.Token != "" && c.Username != "" {
+		problems = append(problems, Problem{"token", "cannot be used together with username"})
+	}
+	if c.Username != "" {
+		problems = required(problems, "password", c.Password)
+	}
+	return problems
+}
+
+func (c *NtfyConfig) build(name string, client *http.Client) (Notifier, error) {
+	return &ntfy{name: name, config: *c, client: client}, nil
+}
+
+// ntfy 通过 JSON 发布接口向主题发送消息
+type ntfy struct {
+	name   string
+	config NtfyConfig
+	client *http.Client
+}
+
+func (n *ntfy) Name() string { return n.name }
+func (n *ntfy) Type() string { return "ntfy" }
+
+func (n *ntfy) Send(ctx context.Context, event Event) error {
+	body := map[string]any{
+		"topic":   n.config.Topic,
+		"title":   title(event),
+		"message": event.Message,
+	}
+	if n.config.Priority != "" {
+		body["priority"] = ntfyPriorities[n.config.Priority]
+	}
+	if len(n.config.Tags) > 0 {
+		body["tags"] = n.config.Tags
+	}
+
+	var headers map[string]string
+	switch {
+	case n.config.Token != "":
+		headers = map[string]string{"Authorization": "Bearer " + n.config.Token}
+	case n.config.Username != "":
+		credentials := base64.StdEncoding.EncodeToString([]byte(n.config.Username + ":" + n.config.Password))
+		headers = map[string]string{"Authorization": "Basic " + credentials}
+	}
+	// JSON 发布需要 POST 到服务器根地址，主题写在请求体中
+	_, err := postJSON(ctx, n.client, strings.TrimRight(withDefault(n.config.Server, defaultNtfyServer), "/")+"/", body, headers)
+	return err
+}
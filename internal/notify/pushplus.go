@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+)
+
+// defaultPushPlusAPIURL PushPlus 的默认发送地址
+const defaultPushPlusAPIURL = "https://www.pushplus.plus/send"
+
+// PushPlusConfig [notifiers.pushplus]
+type PushPlusConfig struct {
+	APIURL    string `toml:"api_url"` // 留空使用 https://www.pushplus.plus/send
+	Token     string `toml:"token" secret:"true"`
+	TokenFile string `toml:"token_file"`
+	Topic     string `toml:"topic"`   // 群组编码，留空发送给自己
+	Channel   string `toml:"channel"` // 发送渠道，如 wechat、webhook、mail，留空使用账号默认设置
+}
+
+func (c *PushPlusConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "token", c.Token)
+	problems = validURL(problems, "api_url", c.APIURL)
+	return problems
+}
+
+func (c *PushPlusConfig) build(name string, client *http.Client) (Notifier, error) {
+	return &pushPlus{name: name, config: *c, client: client}, nil
+}
+
+// pushPlus 通过 PushPlus 推送到微信等
+type pushPlus struct {
+	name   string
+	config PushPlusConfig
+	client *http.Client
+}
+
+func (p *pushPlus) Name() string { return p.name }
+func (p *pushPlus) Type() string { return "pushplus" }
+
+func (p *pushPlus) Send(ctx context.Context, event Event) error {
+	body := map[string]any{
+		"token":    p.config.Token,
+		"title":    title(event),
+		"content":  event.Message,
+		"template": "txt",
+	}
+	if p.config.Topic != "" {
+		body["topic"] = p.config.Topic
+	}
+	if p.config.Channel != "" {
+		body["channel"] = p.config.Channel
+	}
+
+	respBody, err := postJSON(ctx, p.client, withDefault(p.config.APIURL, defaultPushPlusAPIURL), body, nil)
+	if err != nil {
+		return err
+	}
+	return checkResult(respBody, 200)
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// sctpKeyPattern Server酱³ 的 SendKey，形如 sctp<uid>t...
+var sctpKeyPattern = regexp.MustCompile(`^sctp(\d+)t`)
+
+// ServerChanConfig [notifiers.serverchan]
+type ServerChanConfig struct {
+	APIURL      string `toml:"api_url"` // 留空根据 SendKey 自动选择 Server酱 Turbo 或 Server酱³ 的地址
+	SendKey     string `toml:"send_key" secret:"true"`
+	SendKeyFile string `toml:"send_key_file"`
+}
+
+func (c *ServerChanConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "send_key", c.SendKey)
+	problems = validURL(problems, "api_url", c.APIURL)
+	return problems
+}
+
+func (c *ServerChanConfig) build(name string, client *http.Client) (Notifier, error) {
+	return &serverChan{name: name, config: *c, client: client}, nil
+}
+
+// serverChan 通过 Server酱推送到微信等
+type serverChan struct {
+	name   string
+	config ServerChanConfig
+	client *http.Client
+}
+
+func (s *serverChan) Name() string { return s.name }
+func (s *serverChan) Type() string { return "serverchan" }
+
+// url 返回发送地址
+func (s *serverChan) url() string {
+	key := s.config.SendKey
+	switch {
+	case s.config.APIURL != "":
+		return fmt.Sprintf("%s/%s.send", strings.TrimRight(s.config.APIURL, "/"), key)
+	case sctpKeyPattern.MatchString(key):
+		uid := sctpKeyPattern.FindStringSubmatch(key)[1]
+		return fmt.Sprintf("https://%s.push.ft07.com/send/%s.send", uid, key)
+	default:
+		return fmt.Sprintf("https://sctapi.ftqq.com/%s.send", key)
+	}
+}
+
+func (s *serverChan) Send(ctx context.Context, event Event) error {
+	respBody, err := postJSON(ctx, s.client, s.url(), map[string]any{
+		"title": title(event),
+		"desp":  event.Message,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return checkResult(respBody, 0)
+}
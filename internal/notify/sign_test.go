@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// 以下期望值使用 Python hmac/hashlib 独立计算，输入取自钉钉和飞书文档中的示例格式
+
+func TestDingTalkURL(t *testing.T) {
+	tests := []struct {
+		webhookURL string
+		secret     string
+		now        time.Time
+		wantSign   string // 为空表示不签名
+	}{
+		{"https://oapi.dingtalk.com/robot/send?access_token=abc", "this is secret", time.UnixMilli(1577262236757), "hmPWwU+7lVdm3ZZz0r9tSfx0L4Q26jWOZr9+Gs6EZQM="},
+		{"https://oapi.dingtalk.com/robot/send?access_token=abc", "SEC000", time.UnixMilli(1700000000000), "ltBBey5eZrWKh1cPzFIdz3v3xpkc4Tjx4lLsPSHqdtA="},
+		{"https://oapi.dingtalk.com/robot/send?access_token=abc", "", time.UnixMilli(1700000000000), ""},
+	}
+	for _, tt := range tests {
+		d := &dingTalk{config: DingTalkConfig{WebhookURL: tt.webhookURL, Secret: tt.secret}}
+		got, err := d.url(tt.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.wantSign == "" {
+			if got != tt.webhookURL {
+				t.Errorf("url() = %s, want %s", got, tt.webhookURL)
+			}
+			continue
+		}
+
+		u, err := url.Parse(got)
+		if err != nil {
+			t.Fatal(err)
+		}
+		query := u.Query()
+		if query.Get("access_token") != "abc" {
+			t.Errorf("url() = %s, access_token lost", got)
+		}
+		if want := tt.now.UnixMilli(); query.Get("timestamp") != strconv.FormatInt(want, 10) {
+			t.Errorf("timestamp = %s, want %d", query.Get("timestamp"), want)
+		}
+		if query.Get("sign") != tt.wantSign {
+			t.Errorf("sign = %s, want %s", query.Get("sign"), tt.wantSign)
+		}
+	}
+}
+
+func TestFeishuSign(t *testing.T) {
+	tests := []struct {
+		timestamp string
+		secret    string
+		want      string
+	}{
+		{"1599360473", "secret-demo", "tKmNRtQN12XP/+nEz2VONczHKWy/hYIsVBZKzus46Zk="},
+		{"1700000000", "SEC000", "QKhXycVUGrhA1dUEbXA2Vo/zGkv/W88IgYgKNA7Xmk0="},
+	}
+	for _, tt := range tests {
+		if got := feishuSign(tt.timestamp, tt.secret); got != tt.want {
+			t.Errorf("feishuSign(%s, %q) = %s, want %s", tt.timestamp, tt.secret, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SlackConfig [notifiers.slack]，Slack Incoming Webhook
+type SlackConfig struct {
+	// Incoming Webhook 地址，如 https://hooks.slack.com/services/T000/B000/XXX
+	WebhookURL     string `toml:"webhook_url" secret:"true"`
+	WebhookURLFile string `toml:"webhook_url_file"`
+	Channel        string `toml:"channel"`    // 覆盖默认频道，只对旧版 Webhook 有效
+	Username       string `toml:"username"`   // 覆盖显示名称，只对旧版 Webhook 有效
+	IconEmoji      string `toml:"icon_emoji"` // 覆盖头像，如 :globe_with_meridians:，只对旧版 Webhook 有效
+}
+
+func (c *SlackConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "webhook_url", c.WebhookURL)
+	problems = validURL(problems, "webhook_url", c.WebhookURL)
+	return problems
+}
+
+func (c *SlackConfig) build(name string, client *http.Client) (Notifier, error) {
+	return &slack{name: name, config: *c, client: client}, nil
+}
+
+// slack 通过 Incoming Webhook 发送 Block Kit 消息
+type slack struct {
+	name   string
+	config SlackConfig
+	client *http.Client
+}
+
+func (s *slack) Name() string { return s.name }
+func (s *slack) Type() string { return "slack" }
+
+// slackEscape 转义 mrkdwn 中的控制字符
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// slackText 返回 mrkdwn 文本对象
+func slackText(text string) map[string]any {
+	return map[string]any{"type": "mrkdwn", "text": text}
+}
+
+func (s *slack) Send(ctx context.Context, event Event) error {
+	blocks := []map[string]any{
+		{"type": "header", "text": map[string]any{"type": "plain_text", "text": title(event)}},
+		{"type": "section", "text": slackText(slackEscape.Replace(event.Message))},
+	}
+	if fields := eventFields(event); len(fields) > 0 {
+		var texts []map[string]any
+		for _, f := range fields {
+			texts = append(texts, slackText("*"+f.name+"*\n"+slackEscape.Replace(f.value)))
+		}
+		blocks = append(blocks, map[string]any{"type": "section", "fields": texts})
+	}
+	blocks = append(blocks, map[string]any{
+		"type":     "context",
+		"elements": []map[string]any{slackText(slackEscape.Replace(event.Hostname) + " · " + event.Time.Format(time.RFC3339))},
+	})
+
+	body := map[string]any{
+		"text":   event.Message, // 不支持 Block Kit 的客户端和通知中显示的内容
+		"blocks": blocks,
+	}
+	for key, value := range map[string]string{"channel": s.config.Channel, "username": s.config.Username, "icon_emoji": s.config.IconEmoji} {
+		if value != "" {
+			body[key] = value
+		}
+	}
+	_, err := postJSON(ctx, s.client, s.config.WebhookURL, body, nil)
+	return err
+}
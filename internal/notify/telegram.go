@@ -0,0 +1,68 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultTelegramAPIURL Telegram Bot API 的默认地址
+const defaultTelegramAPIURL = "https://api.telegram.org"
+
+// TelegramConfig [notifiers.telegram]
+type TelegramConfig struct {
+	APIURL    string `toml:"api_url"` // 自定义 Bot API 地址（反向代理），留空使用 https://api.telegram.org
+	Token     string `toml:"token" secret:"true"`
+	TokenFile string `toml:"token_file"`
+	ChatID    string `toml:"chat_id"`
+}
+
+func (c *TelegramConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "token", c.Token)
+	problems = required(problems, "chat_id", c.ChatID)
+	problems = validURL(problems, "api_url", c.APIURL)
+	return problems
+}
+
+func (c *TelegramConfig) build(name string, client *http.Client) (Notifier, error) {
+	return &telegram{name: name, config: *c, client: client}, nil
+}
+
+// telegram 通过 Bot API 的 sendMessage 发送文本消息
+type telegram struct {
+	name   string
+	config TelegramConfig
+	client *http.Client
+}
+
+func (t *telegram) Name() string { return t.name }
+func (t *telegram) Type() string { return "telegram" }
+
+func (t *telegram) Send(ctx context.Context, event Event) error {
+	apiURL := t.config.APIURL
+	if apiURL == "" {
+		apiURL = defaultTelegramAPIURL
+	}
+	url := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(apiURL, "/"), t.config.Token)
+
+	respBody, err := postJSON(ctx, t.client, url, map[string]any{
+		"chat_id":                  t.config.ChatID,
+		"text":                     event.Message,
+		"disable_web_page_preview": true,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(respBody, &result); err == nil && !result.OK {
+		return fmt.Errorf("telegram: %s", result.Description)
+	}
+	return nil
+}
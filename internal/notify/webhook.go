@@ -0,0 +1,144 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// defaultSignatureHeader 签名默认使用的请求头
+const defaultSignatureHeader = "X-CfDDNS-Signature"
+
+// WebhookConfig [notifiers.webhook]
+type WebhookConfig struct {
+	URL         string            `toml:"url"`
+	Method      string            `toml:"method"`       // 留空使用 POST
+	Headers     map[string]string `toml:"headers"`      // 额外的请求头
+	ContentType string            `toml:"content_type"` // 留空使用 application/json
+	// 请求体模板（text/template），数据为 Event，留空则发送 Event 的 JSON
+	// 可用 {{json .Message}} 输出带引号并转义的 JSON 字符串
+	Body string `toml:"body"`
+	// 设置后使用 HMAC-SHA256 对请求体签名，签名以 sha256=<hex> 的形式写入 signature_header
+	Secret          string `toml:"secret" secret:"true"`
+	SecretFile      string `toml:"secret_file"`
+	SignatureHeader string `toml:"signature_header"` // 留空使用 X-CfDDNS-Signature
+}
+
+// templateFuncs 模板中可用的函数
+var templateFuncs = template.FuncMap{
+	// json 把值编码为 JSON，字符串会带上引号并转义
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// parseTemplate 解析通知模板，text 为空时返回 nil
+func parseTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return template.New(name).Funcs(templateFuncs).Parse(text)
+}
+
+// checkTemplate 校验模板能否解析，并使用示例事件试渲染，以发现拼错的字段名
+func checkTemplate(problems []Problem, field, text string) []Problem {
+	tmpl, err := parseTemplate(field, text)
+	if err == nil && tmpl != nil {
+		_, err = render(tmpl, sampleEvent)
+	}
+	if err != nil {
+		return append(problems, Problem{field, "invalid template: " + err.Error()})
+	}
+	return problems
+}
+
+// render 使用 event 渲染模板
+func render(tmpl *template.Template, event Event) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c *WebhookConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "url", c.URL)
+	problems = validURL(problems, "url", c.URL)
+	switch strings.ToUpper(c.Method) {
+	case "", http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		problems = append(problems, Problem{"method", fmt.Sprintf("invalid value %q, must be POST, PUT or PATCH", c.Method)})
+	}
+	return checkTemplate(problems, "body", c.Body)
+}
+
+func (c *WebhookConfig) build(name string, client *http.Client) (Notifier, error) {
+	body, err := parseTemplate("body", c.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook{name: name, config: *c, body: body, client: client}, nil
+}
+
+// webhook 把事件发送到任意 HTTP 地址
+type webhook struct {
+	name   string
+	config WebhookConfig
+	body   *template.Template // 为空时发送 Event 的 JSON
+	client *http.Client
+}
+
+func (w *webhook) Name() string { return w.name }
+func (w *webhook) Type() string { return "webhook" }
+
+func (w *webhook) Send(ctx context.Context, event Event) error {
+	var body []byte
+	var err error
+	if w.body != nil {
+		body, err = render(w.body, event)
+	} else {
+		body, err = json.Marshal(event)
+	}
+	if err != nil {
+		return err
+	}
+
+	method := strings.ToUpper(w.config.Method)
+	if method == "" {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequestWithContext(ctx, method, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	contentType := w.config.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range w.config.Headers {
+		req.Header.Set(k, v)
+	}
+	if w.config.Secret != "" {
+		header := w.config.SignatureHeader
+		if header == "" {
+			header = defaultSignatureHeader
+		}
+		req.Header.Set(header, "sha256="+sign(w.config.Secret, body))
+	}
+
+	_, err = doRequest(w.client, req)
+	return err
+}
+
+// sign 返回 body 的 HMAC-SHA256 签名（十六进制）
+func sign(secret string, body []byte) string {
+	return hex.EncodeToString(hmacSHA256([]byte(secret), body))
+}
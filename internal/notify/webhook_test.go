@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	// RFC 4231 HMAC-SHA256 测试向量
+	tests := []struct {
+		secret string
+		body   string
+		want   string
+	}{
+		{"Jefe", "what do ya want for nothing?", "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"},
+		{
+			"\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b\x0b",
+			"Hi There",
+			"b0344c61d8db38535ca8afceaf0bf12b881dc200c9833da726e9376c2e32cff7",
+		},
+	}
+	for _, tt := range tests {
+		if got := sign(tt.secret, []byte(tt.body)); got != tt.want {
+			t.Errorf("sign(%q, %q) = %s, want %s", tt.secret, tt.body, got, tt.want)
+		}
+	}
+}
+
+func TestWebhookSignatureHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string // signature_header，留空使用默认请求头
+		want   string
+	}{
+		{"default header", "", defaultSignatureHeader},
+		{"custom header", "X-Hub-Signature-256", "X-Hub-Signature-256"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotSignature, gotBody string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotSignature = r.Header.Get(tt.want)
+				body, _ := io.ReadAll(r.Body)
+				gotBody = string(body)
+			}))
+			defer srv.Close()
+
+			n, err := New(Config{
+				Name: "hook",
+				Type: "webhook",
+				Webhook: &WebhookConfig{
+					URL:             srv.URL,
+					Body:            "what do ya want for nothing?",
+					Secret:          "Jefe",
+					SignatureHeader: tt.header,
+				},
+			}, srv.Client())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := n.Send(context.Background(), sampleEvent); err != nil {
+				t.Fatal(err)
+			}
+
+			if gotBody != "what do ya want for nothing?" {
+				t.Errorf("body = %q", gotBody)
+			}
+			if want := "sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"; gotSignature != want {
+				t.Errorf("%s = %q, want %q", tt.want, gotSignature, want)
+			}
+		})
+	}
+}
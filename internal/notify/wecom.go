@@ -0,0 +1,51 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+)
+
+// WeComConfig [notifiers.wecom]，企业微信群机器人
+type WeComConfig struct {
+	// 机器人的 Webhook 地址，如 https://qyapi.weixin.qq.com/cgi-bin/webhook/send?key=xxx
+	WebhookURL       string   `toml:"webhook_url" secret:"true"`
+	WebhookURLFile   string   `toml:"webhook_url_file"`
+	MentionedMobiles []string `toml:"mentioned_mobiles"` // 需要 @ 的成员手机号，@all 表示所有人
+}
+
+func (c *WeComConfig) validate() []Problem {
+	var problems []Problem
+	problems = required(problems, "webhook_url", c.WebhookURL)
+	problems = validURL(problems, "webhook_url", c.WebhookURL)
+	return problems
+}
+
+func (c *WeComConfig) build(name string, client *http.Client) (Notifier, error) {
+	return &weCom{name: name, config: *c, client: client}, nil
+}
+
+// weCom 通过企业微信群机器人发送文本消息
+type weCom struct {
+	name   string
+	config WeComConfig
+	client *http.Client
+}
+
+func (w *weCom) Name() string { return w.name }
+func (w *weCom) Type() string { return "wecom" }
+
+func (w *weCom) Send(ctx context.Context, event Event) error {
+	text := map[string]any{"content": event.Message}
+	if len(w.config.MentionedMobiles) > 0 {
+		text["mentioned_mobile_list"] = w.config.MentionedMobiles
+	}
+
+	respBody, err := postJSON(ctx, w.client, w.config.WebhookURL, map[string]any{
+		"msgtype": "text",
+		"text":    text,
+	}, nil)
+	if err != nil {
+		return err
+	}
+	return checkResult(respBody, 0)
+}
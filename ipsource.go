@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// IPSource 公网 IP 的获取来源
+type IPSource interface {
+	// Name 返回来源名称，用于日志
+	Name() string
+	// GetIP 获取指定协议族（"4" 或 "6"）的 IP 地址
+	GetIP(ctx context.Context, ipType string) (string, error)
+}
+
+// IPSourceConfig 单个 IP 获取来源的配置
+type IPSourceConfig struct {
+	Type string `toml:"type"` // 来源类型：http（默认）或 interface
+	URL  string `toml:"url"`  // http 类型使用的地址
+
+	// interface 类型使用
+	Interface      string `toml:"interface"`       // 网卡名称
+	Filter         string `toml:"filter"`          // 地址过滤条件，CIDR 或正则
+	Prefer         string `toml:"prefer"`          // stable（默认）或 temporary，优先使用稳定地址还是临时（隐私）地址
+	SkipDeprecated *bool  `toml:"skip_deprecated"` // 是否跳过已弃用的地址，默认跳过
+	AllowPrivate   bool   `toml:"allow_private"`   // 是否允许内网地址（RFC 1918、CGNAT、IPv6 ULA），默认只使用公网地址
+}
+
+// httpIPSource 通过 HTTP 接口获取 IP，接口需直接返回 IP 文本
+type httpIPSource struct {
+	url string
+}
+
+// Name 返回隐藏了 Token 等敏感参数的 URL
+func (s *httpIPSource) Name() string {
+	if u, err := url.Parse(s.url); err == nil {
+		return redactURL(u)
+	}
+	// 无法解析时去掉查询参数
+	base, _, _ := strings.Cut(s.url, "?")
+	return base
+}
+
+func (s *httpIPSource) GetIP(ctx context.Context, ipType string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.url, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build request for %s: %v", s.Name(), stripURL(err))
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch IP from %s: %v", s.Name(), stripURL(err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("non-200 status code from %s: %d", s.Name(), resp.StatusCode)
+	}
+
+	// IP 地址不会很长，限制读取长度以防返回整个错误页面
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", fmt.Errorf("failed to read response body: %v", err)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// stripURL 去掉 *url.Error 中未隐藏敏感参数的完整 URL，只保留底层错误
+func stripURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// newIPSource 根据配置创建 IP 获取来源
+func newIPSource(sc IPSourceConfig) (IPSource, error) {
+	switch sc.Type {
+	case "", "http":
+		if sc.URL == "" {
+			return nil, fmt.Errorf("http IP source requires url")
+		}
+		return &httpIPSource{url: sc.URL}, nil
+	case "interface":
+		return newInterfaceIPSource(sc)
+	default:
+		return nil, fmt.Errorf("unknown IP source type: %s", sc.Type)
+	}
+}
+
+// ipSources 返回指定协议族的 IP 获取来源列表
+// 未配置 ipv4_sources/ipv6_sources 时使用 get_ipv4_url/get_ipv6_url
+func (cf *CfDDNS) ipSources(ipType string) ([]IPSource, error) {
+	configs := cf.Config.IPv4Sources
+	fallback := cf.Config.GetIPv4URL
+	if ipType == "6" {
+		configs = cf.Config.IPv6Sources
+		fallback = cf.Config.GetIPv6URL
+	}
+	if len(configs) == 0 {
+		configs = []IPSourceConfig{{URL: fallback}}
+	}
+
+	sources := make([]IPSource, 0, len(configs))
+	for _, sc := range configs {
+		source, err := newIPSource(sc)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// validateIP 校验来源返回的内容是否为合法的对应协议族 IP
+func validateIP(ipType, ip string) error {
+	valid := isValidIPv4(ip)
+	if ipType == "6" {
+		valid = isValidIPv6(ip)
+	}
+	if !valid {
+		if len(ip) > 64 {
+			ip = ip[:64] + "..."
+		}
+		return fmt.Errorf("invalid IPv%s address %q", ipType, ip)
+	}
+	return nil
+}
+
+// detectIP 按顺序查询来源获取公网 IP
+// ip_quorum 大于 1 时查询所有来源，至少 ip_quorum 个来源返回相同 IP 才视为成功
+func (cf *CfDDNS) detectIP(ctx context.Context, ipType string) (string, error) {
+	sources, err := cf.ipSources(ipType)
+	if err != nil {
+		return "", err
+	}
+
+	quorum := cf.Config.IPQuorum
+	votes := make(map[string]int)
+	var errs []string
+	for _, source := range sources {
+		ip, err := source.GetIP(ctx, ipType)
+		if err == nil {
+			err = validateIP(ipType, ip)
+		}
+		metrics.ipLookups.inc(source.Name(), ipType, resultLabel(err))
+		if err != nil {
+			slog.Warn("IP source failed", "source", source.Name(), "ip_type", ipType, "error", err)
+			errs = append(errs, fmt.Sprintf("%s: %v", source.Name(), err))
+			continue
+		}
+
+		votes[ip]++
+		if quorum <= 1 || votes[ip] >= quorum {
+			slog.Debug("IP detected", "source", source.Name(), "ip_type", ipType, "ip", ip, "votes", votes[ip])
+			return ip, nil
+		}
+		slog.Debug("IP source vote", "source", source.Name(), "ip_type", ipType, "ip", ip, "votes", votes[ip])
+	}
+
+	if quorum > 1 && len(votes) > 0 {
+		return "", fmt.Errorf("no IPv%s address reached quorum %d of %d sources: %v", ipType, quorum, len(sources), votes)
+	}
+	return "", fmt.Errorf("all IPv%s sources failed: %s", ipType, strings.Join(errs, "; "))
+}
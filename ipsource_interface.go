@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+)
+
+// addrFlags 地址的附加状态，仅部分平台可以获取
+type addrFlags struct {
+	Temporary  bool // 临时地址（隐私扩展）
+	Deprecated bool // 已弃用的地址
+}
+
+// interfaceIPSource 直接从本机网卡读取 IP，无需访问外部服务
+type interfaceIPSource struct {
+	iface          string
+	cidr           *net.IPNet     // filter 为 CIDR 时使用
+	pattern        *regexp.Regexp // filter 为正则时使用
+	preferTemp     bool
+	skipDeprecated bool
+	allowPrivate   bool
+}
+
+// cgnatNet 运营商级 NAT 使用的共享地址段（RFC 6598）
+var cgnatNet = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPrivateIP 判断是否为内网地址：RFC 1918、CGNAT（100.64.0.0/10）或 IPv6 ULA（fc00::/7）
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsPrivate() || cgnatNet.Contains(ip)
+}
+
+func newInterfaceIPSource(sc IPSourceConfig) (*interfaceIPSource, error) {
+	if sc.Interface == "" {
+		return nil, fmt.Errorf("interface IP source requires interface")
+	}
+
+	s := &interfaceIPSource{iface: sc.Interface, skipDeprecated: true, allowPrivate: sc.AllowPrivate}
+	if sc.SkipDeprecated != nil {
+		s.skipDeprecated = *sc.SkipDeprecated
+	}
+
+	switch sc.Prefer {
+	case "", "stable":
+	case "temporary":
+		s.preferTemp = true
+	default:
+		return nil, fmt.Errorf("invalid prefer value for interface %s: %s (stable or temporary)", sc.Interface, sc.Prefer)
+	}
+
+	// filter 可以是 CIDR，也可以是匹配地址文本的正则
+	if sc.Filter != "" {
+		if _, cidr, err := net.ParseCIDR(sc.Filter); err == nil {
+			s.cidr = cidr
+		} else {
+			pattern, err := regexp.Compile(sc.Filter)
+			if err != nil {
+				return nil, fmt.Errorf("invalid filter for interface %s: %v", sc.Interface, err)
+			}
+			s.pattern = pattern
+		}
+	}
+	return s, nil
+}
+
+func (s *interfaceIPSource) Name() string {
+	return "interface:" + s.iface
+}
+
+// match 判断地址是否满足协议族及过滤条件
+func (s *interfaceIPSource) match(ipType string, ip net.IP) bool {
+	if (ip.To4() != nil) != (ipType == "4") {
+		return false
+	}
+	// 排除回环、链路本地等地址
+	if !ip.IsGlobalUnicast() {
+		return false
+	}
+	// 默认只使用公网地址，避免把内网地址发布到公共 DNS
+	if !s.allowPrivate && isPrivateIP(ip) {
+		return false
+	}
+	switch {
+	case s.cidr != nil:
+		return s.cidr.Contains(ip)
+	case s.pattern != nil:
+		return s.pattern.MatchString(ip.String())
+	default:
+		return true
+	}
+}
+
+func (s *interfaceIPSource) GetIP(ctx context.Context, ipType string) (string, error) {
+	iface, err := net.InterfaceByName(s.iface)
+	if err != nil {
+		return "", fmt.Errorf("failed to find interface %s: %v", s.iface, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", fmt.Errorf("failed to list addresses of %s: %v", s.iface, err)
+	}
+
+	flags := interfaceAddrFlags(s.iface)
+
+	var preferred, fallback string
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !s.match(ipType, ipNet.IP) {
+			continue
+		}
+		ip := ipNet.IP.String()
+		f := flags[ip]
+		if s.skipDeprecated && f.Deprecated {
+			continue
+		}
+		if f.Temporary == s.preferTemp {
+			if preferred == "" {
+				preferred = ip
+			}
+		} else if fallback == "" {
+			fallback = ip
+		}
+	}
+
+	if preferred != "" {
+		return preferred, nil
+	}
+	if fallback != "" {
+		return fallback, nil
+	}
+	return "", fmt.Errorf("no matching IPv%s address on interface %s", ipType, s.iface)
+}
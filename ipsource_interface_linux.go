@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 参考 linux/if_addr.h
+const (
+	ifaFlagTemporary  = 0x01
+	ifaFlagDeprecated = 0x20
+)
+
+// interfaceAddrFlags 从 /proc/net/if_inet6 读取网卡上 IPv6 地址的状态
+// 每行格式：地址 网卡序号 前缀长度 作用域 标志 网卡名
+func interfaceAddrFlags(iface string) map[string]addrFlags {
+	result := make(map[string]addrFlags)
+
+	f, err := os.Open("/proc/net/if_inet6")
+	if err != nil {
+		return result
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 6 || fields[5] != iface || len(fields[0]) != 32 {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[4], 16, 32)
+		if err != nil {
+			continue
+		}
+
+		ip := make(net.IP, net.IPv6len)
+		for i := range net.IPv6len {
+			b, err := strconv.ParseUint(fields[0][i*2:i*2+2], 16, 8)
+			if err != nil {
+				ip = nil
+				break
+			}
+			ip[i] = byte(b)
+		}
+		if ip == nil {
+			continue
+		}
+
+		result[ip.String()] = addrFlags{
+			Temporary:  flags&ifaFlagTemporary != 0,
+			Deprecated: flags&ifaFlagDeprecated != 0,
+		}
+	}
+	return result
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package main
+
+// interfaceAddrFlags 当前平台无法获取地址状态，所有地址都视为稳定地址
+func interfaceAddrFlags(iface string) map[string]addrFlags {
+	return nil
+}
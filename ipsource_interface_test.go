@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net"
+	"testing"
+)
+
+func TestInterfaceIPSourceMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		config IPSourceConfig
+		ipType string
+		ip     string
+		want   bool
+	}{
+		{"public IPv4", IPSourceConfig{}, "4", "203.0.113.10", true},
+		{"RFC 1918", IPSourceConfig{}, "4", "192.168.1.10", false},
+		{"CGNAT", IPSourceConfig{}, "4", "100.64.1.1", false},
+		{"CGNAT edge", IPSourceConfig{}, "4", "100.128.0.1", true},
+		{"public IPv6", IPSourceConfig{}, "6", "2001:db8::1", true},
+		{"ULA", IPSourceConfig{}, "6", "fd00::1", false},
+		{"link-local", IPSourceConfig{AllowPrivate: true}, "6", "fe80::1", false},
+		{"loopback", IPSourceConfig{AllowPrivate: true}, "4", "127.0.0.1", false},
+		{"wrong family", IPSourceConfig{}, "6", "203.0.113.10", false},
+		{"allow_private RFC 1918", IPSourceConfig{AllowPrivate: true}, "4", "10.0.0.1", true},
+		{"allow_private ULA", IPSourceConfig{AllowPrivate: true}, "6", "fd00::1", true},
+		{"private filter without allow_private", IPSourceConfig{Filter: "10.0.0.0/8"}, "4", "10.0.0.1", false},
+		{"CIDR filter", IPSourceConfig{Filter: "2001:db8:1::/48"}, "6", "2001:db8:2::1", false},
+		{"regex filter", IPSourceConfig{Filter: `^2001:db8:`}, "6", "2001:db8::1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Interface = "eth0"
+			s, err := newInterfaceIPSource(tt.config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.match(tt.ipType, net.ParseIP(tt.ip)); got != tt.want {
+				t.Errorf("match(%s, %s) = %v, want %v", tt.ipType, tt.ip, got, tt.want)
+			}
+		})
+	}
+}
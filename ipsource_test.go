@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHTTPIPSourceErrorsHideSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+	defer srv.Close()
+
+	tests := []struct {
+		name string
+		url  string
+		want string // 错误中应包含的内容
+	}{
+		{"non-200 status", srv.URL + "/ip?token=s3cret", "non-200 status code"},
+		{"connection refused", closed.URL + "/ip?key=s3cret", "failed to fetch IP"},
+		{"invalid url", "http://[::1/ip?token=s3cret", "failed to build request"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := (&httpIPSource{url: tt.url}).GetIP(context.Background(), "4")
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if strings.Contains(err.Error(), "s3cret") {
+				t.Errorf("error leaks the secret: %v", err)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// logOutput 日志输出位置，默认只输出到控制台
+var logOutput io.Writer = os.Stdout
+
+// logFile 当前使用的日志文件，重新加载配置时需要关闭
+var logFile *dailyFileWriter
+
+const (
+	logFilePrefix = "cfddns-"
+	logFileSuffix = ".log"
+	logDayLayout  = "2006-01-02"
+)
+
+// dailyFileWriter 按天切分的日志文件，切分时清理超过保留天数的旧日志
+type dailyFileWriter struct {
+	mu        sync.Mutex
+	dir       string
+	retention int
+	day       string
+	file      *os.File
+}
+
+func newDailyFileWriter(dir string, retention int) (*dailyFileWriter, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory %s: %v", dir, err)
+	}
+	w := &dailyFileWriter{dir: dir, retention: retention}
+	if err := w.rotate(time.Now()); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *dailyFileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if now.Format(logDayLayout) != w.day {
+		if err := w.rotate(now); err != nil {
+			return 0, err
+		}
+	}
+	return w.file.Write(p)
+}
+
+// Close 关闭当前日志文件
+func (w *dailyFileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return nil
+	}
+	err := w.file.Close()
+	w.file = nil
+	return err
+}
+
+// rotate 切换到 now 对应日期的日志文件
+func (w *dailyFileWriter) rotate(now time.Time) error {
+	day := now.Format(logDayLayout)
+	path := filepath.Join(w.dir, logFilePrefix+day+logFileSuffix)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file %s: %v", path, err)
+	}
+	if w.file != nil {
+		w.file.Close()
+	}
+	w.file = file
+	w.day = day
+	w.prune(now)
+	return nil
+}
+
+// prune 删除超过保留天数的日志文件
+func (w *dailyFileWriter) prune(now time.Time) {
+	if w.retention <= 0 {
+		return
+	}
+	entries, err := os.ReadDir(w.dir)
+	if err != nil {
+		return
+	}
+
+	cutoff := now.AddDate(0, 0, -w.retention).Format(logDayLayout)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, logFilePrefix) || !strings.HasSuffix(name, logFileSuffix) {
+			continue
+		}
+		day := strings.TrimSuffix(strings.TrimPrefix(name, logFilePrefix), logFileSuffix)
+		if _, err := time.Parse(logDayLayout, day); err != nil {
+			continue
+		}
+		// 日期格式固定，可以直接按字符串比较
+		if day < cutoff {
+			os.Remove(filepath.Join(w.dir, name))
+		}
+	}
+}
+
+// httpClient 访问 Cloudflare 和 IP 来源使用的客户端，调试模式下会记录请求和响应
+var httpClient = http.DefaultClient
+
+// notifyHTTPClient 通知渠道使用的客户端，调试模式下也不记录
+// 通知请求的 URL 路径、请求头和请求体中都可能含有渠道凭据，如 Webhook 地址中的 token、PushPlus 的 token
+var notifyHTTPClient = http.DefaultClient
+
+// logTimeLayout 文本日志的时间格式
+const logTimeLayout = "2006-01-02 15:04:05"
+
+// setupLogging 根据配置初始化 slog
+// 设置 log_path 后日志同时写入文件和控制台，debug 为 true 时输出调试日志并记录 HTTP 请求
+// 重新加载配置时可以再次调用
+func setupLogging(config Config) error {
+	if logFile != nil {
+		logFile.Close()
+		logFile = nil
+	}
+	logOutput = os.Stdout
+	httpClient = http.DefaultClient
+
+	var setupErr error
+	if config.LogPath != "" {
+		fileWriter, err := newDailyFileWriter(config.LogPath, config.LogRetention)
+		if err != nil {
+			setupErr = err
+		} else {
+			// 同时输出到控制台，便于 Docker 查看日志
+			logFile = fileWriter
+			logOutput = io.MultiWriter(os.Stdout, fileWriter)
+		}
+	}
+
+	level := slog.LevelInfo
+	if config.Debug {
+		level = slog.LevelDebug
+		httpClient = &http.Client{Transport: &debugTransport{next: http.DefaultTransport}}
+	}
+
+	var handler slog.Handler
+	switch config.LogFormat {
+	case "json":
+		handler = slog.NewJSONHandler(logOutput, &slog.HandlerOptions{Level: level})
+	default:
+		handler = slog.NewTextHandler(logOutput, &slog.HandlerOptions{
+			Level: level,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				if len(groups) == 0 && a.Key == slog.TimeKey {
+					return slog.String(slog.TimeKey, a.Value.Time().Format(logTimeLayout))
+				}
+				return a
+			},
+		})
+	}
+	slog.SetDefault(slog.New(handler))
+	return setupErr
+}
+
+// recordLogger 返回带有记录信息的 logger
+func recordLogger(rec RecordConfig, ipType string) *slog.Logger {
+	return slog.With("zone", rec.ZoneID, "record", rec.Name, "ip_type", ipType)
+}
+
+// secretQueryKeyset 调试日志中需要隐藏的查询参数
+var secretQueryKeyset = []string{"token", "key", "secret", "sign", "password"}
+
+// redactURL 隐藏 URL 中敏感的查询参数
+func redactURL(u *url.URL) string {
+	redacted := *u
+	query := u.Query()
+	for key := range query {
+		lower := strings.ToLower(key)
+		for _, secret := range secretQueryKeyset {
+			if strings.Contains(lower, secret) {
+				query.Set(key, "***")
+				break
+			}
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
+
+// loggedHeaders 调试日志中原样记录的请求头，其余请求头的值都会被隐藏
+var loggedHeaders = []string{"Accept", "Content-Length", "Content-Type", "User-Agent"}
+
+// redactHeaders 返回用于调试日志的请求头，不在 loggedHeaders 中的值替换为 ***
+func redactHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for key := range header {
+		value := "***"
+		if slices.Contains(loggedHeaders, http.CanonicalHeaderKey(key)) {
+			value = header.Get(key)
+		}
+		headers[key] = value
+	}
+	return headers
+}
+
+// debugTransport 在调试模式下记录 HTTP 请求和响应，除 loggedHeaders 外的请求头都会被隐藏
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	headers := redactHeaders(req.Header)
+
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			body.Close()
+		}
+	}
+	slog.Debug("HTTP request", "method", req.Method, "url", redactURL(req.URL), "headers", headers, "body", string(reqBody))
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		slog.Debug("HTTP request failed", "method", req.Method, "url", redactURL(req.URL), "duration", time.Since(start), "error", err)
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	if err != nil {
+		return nil, err
+	}
+	slog.Debug("HTTP response", "method", req.Method, "url", redactURL(req.URL), "status", resp.StatusCode, "duration", time.Since(start), "body", string(respBody))
+	return resp, nil
+}
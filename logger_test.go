@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cfddns/internal/notify"
+)
+
+// captureDebugLog 把调试日志写入返回的 buffer，测试结束时恢复默认 logger
+func captureDebugLog(t *testing.T) *bytes.Buffer {
+	var buf bytes.Buffer
+	old := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	t.Cleanup(func() { slog.SetDefault(old) })
+	return &buf
+}
+
+func TestRedactHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("User-Agent", "cfddns")
+	header.Set("Authorization", "Bearer cf-token")
+	header.Set("X-Api-Key", "api-key")
+	header.Set("X-Gotify-Key", "gotify-key")
+	header.Set("X-CfDDNS-Signature", "sha256=abc")
+
+	got := redactHeaders(header)
+	want := map[string]string{
+		"Content-Type":       "application/json",
+		"User-Agent":         "cfddns",
+		"Authorization":      "***",
+		"X-Api-Key":          "***",
+		"X-Gotify-Key":       "***",
+		"X-Cfddns-Signature": "***",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("%s = %q, want %q", key, got[key], value)
+		}
+	}
+}
+
+func TestDebugTransportHidesSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "203.0.113.1")
+	}))
+	defer srv.Close()
+
+	buf := captureDebugLog(t)
+	client := &http.Client{Transport: &debugTransport{next: http.DefaultTransport}}
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+"/ip?token=query-secret", nil)
+	req.Header.Set("X-Api-Key", "header-secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	out := buf.String()
+	for _, secret := range []string{"query-secret", "header-secret"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("debug log contains %q:\n%s", secret, out)
+		}
+	}
+	if !strings.Contains(out, "203.0.113.1") {
+		t.Errorf("debug log misses the response body:\n%s", out)
+	}
+}
+
+func TestDebugModeSkipsNotifierRequests(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"code":200}`)
+	}))
+	defer srv.Close()
+
+	config := Config{
+		Debug: true,
+		Notifiers: []notify.Config{{
+			Name:     "pushplus",
+			Type:     "pushplus",
+			PushPlus: &notify.PushPlusConfig{APIURL: srv.URL + "/send", Token: "pushplus-token"},
+		}},
+	}
+	if err := setupLogging(config); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { setupLogging(Config{}) })
+	buf := captureDebugLog(t)
+
+	notifiers := buildNotifiers(config)
+	if len(notifiers) != 1 {
+		t.Fatalf("got %d notifiers, want 1", len(notifiers))
+	}
+	if err := notifiers[0].Send(context.Background(), notify.Event{Type: notify.EventTest, Message: "test"}); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); strings.Contains(out, "pushplus-token") || strings.Contains(out, "HTTP request") {
+		t.Errorf("notifier request logged in debug mode:\n%s", out)
+	}
+}
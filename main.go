@@ -2,153 +2,69 @@ package main
 
 import (
 	"bufio"
-	"bytes"
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net"
-	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"regexp"
 	"runtime"
+	"slices"
 	"strings"
+	"syscall"
 	"time"
 
-	"github.com/pelletier/go-toml/v2"
+	"cfddns/internal/cloudflare"
+	"cfddns/internal/notify"
 )
 
 const Version = "v0.0.1"
 
-type Config struct {
-	CFApiToken   string `toml:"cf_api_token"`
-	CFZoneID     string `toml:"cf_zone_id"`
-	CFRecordName string `toml:"cf_record_name"`
-	CFIPType     string `toml:"cf_ip_type"`
-	AddRecordIfMissing bool   `toml:"add_record_if_missing"`
-	Interval     int    `toml:"interval"`
-	KeepRetry  	 int    `toml:"keep_retry"`
-	RetryCount   int    `toml:"retry_count"`
-	GetIPv4URL   string `toml:"get_ipv4_url"`
-	GetIPv6URL   string `toml:"get_ipv6_url"`
-	Notify       bool   `toml:"notify"`
-	TgApiUrl     string `toml:"tg_api_url"` // 将 TG_PROXY_URL 改为 TG_API_URL
-	TGToken      string `toml:"tg_token"`
-	TGChatID     string `toml:"tg_chat_id"`
-	Debug        bool   `toml:"debug"`
-	LogPath      string `toml:"log_path"`
-	LogRetention int    `toml:"log_retention"` // 日志保留天数
-}
-
 type CfDDNS struct {
-	Config Config
-}
-
-func logMessage(message string) {
-	currentTime := time.Now().Format("2024-11-25 15:04:05")
-	fmt.Printf("[%s] %s\n", currentTime, message)
+	Config        Config
+	configPath    string
+	flagOverrides map[string]string // 命令行参数覆盖的配置项，重新加载配置时再次应用
+	oneShot       bool              // 单次执行的命令，获取 IP 时忽略 keep_retry
+	status        *statusTracker    // 每条记录的同步状态，供 HTTP API 查询
+	httpAPI       *httpAPI
+	triggers      chan triggerSource // 请求立即同步，由信号和 HTTP API 共用
+	api           *cloudflare.Client
+	state         *stateStore
+	notifiers     []notifier
 }
 
-func loadConfig() Config {
-	confPath := "conf.toml"
-
-	// 检查配置文件是否存在
-	if _, err := os.Stat(confPath); os.IsNotExist(err) {
-		log.Printf("Config file not found. Creating a default config file at %s", confPath)
-		createDefaultConfig(confPath)
-	}
-
-	// 读取配置文件
-	data, err := os.ReadFile(confPath)
-	if err != nil {
-		logMessage(fmt.Sprintf("Error reading config: %v", err))
-		os.Exit(1)
-	}
-
-	var config Config
-	// 解析配置文件
-	err = toml.Unmarshal(data, &config)
-	if err != nil {
-		logMessage(fmt.Sprintf("Error parsing config: %v", err))
-		os.Exit(1)
-	}
-
-	// 如果未配置 RetryCount，设置默认值
-	if config.RetryCount == 0 {
-		config.RetryCount = 3
+// newCfDDNS 根据配置创建 CfDDNS 实例
+func newCfDDNS(config Config) *CfDDNS {
+	cf := &CfDDNS{
+		status:   newStatusTracker(),
+		triggers: make(chan triggerSource, 1),
 	}
-
-	// 如果未设置 TG_API_URL，留空使用默认值
-	if config.TgApiUrl == "" {
-		config.TgApiUrl = "https://api.telegram.org"
-	}
-
-	// 如果未设置 GetIPv4URL，留空使用默认值https://4.ipw.cn
-	if config.GetIPv4URL == "" {
-		config.GetIPv4URL = "https://4.ipw.cn"
-	}
-
-	return config
+	cf.httpAPI = &httpAPI{status: cf.status, trigger: cf.triggers}
+	cf.applyConfig(config)
+	return cf
 }
 
-func createDefaultConfig(configPath string) {
-	defaultConfig := `
-# Cloudflare API配置
-cf_api_token = "your_CF_API_TOKEN_here"  # Cloudflare API Token
-cf_zone_id = "Your_CF_ZONE_ID_HERE"    # Cloudflare Zone ID
-cf_record_name = "YOUR_DOMAIN_HERE"  # 要更新的记录名称
-
-# IP类型，用于指定获取IPv4还是IPv6
-cf_ip_type = "46"  # 支持值：4（仅更新 IPv4），6（仅更新 IPv6），46（同时更新 IPv4 和 IPv6）
-
-# 如果 DNS 记录不存在，是否自动添加
-add_record_if_missing = true
-
-# 执行间隔，单位为秒
-interval = 60  # 每1分钟执行一次
-
-# IP获取一直重试
-# 1为一直重试，其他为不一直重试
-keep_retry = 1
-# IP获取重试次数
-retry_count = 3
-
-# 获取IPv4地址的URL
-get_ipv4_url = "https://4.ipw.cn"
-
-# 获取IPv6地址的URL
-get_ipv6_url = "https://6.ipw.cn"
-# 获取公网 IPv4 和 IPv6 地址的 URL,备选
-# get_ipv4_url = "https://api64.ipify.org"
-# get_ipv6_url = "https://api6.ipify.org"
-
-# Telegram配置
-# 变动推送通知,1通知，0不通知
-notify = false
-tg_api_url = ""  # 自定义 Telegram API URL，如果不需要，留空
-tg_token = "Your_tg_bot_token_here"
-tg_chat_id = "Your_tg_chat_id_here"
-
-# 调试模式
-debug = false
-
-# 日志设置
-log_path = ''
-# 日志保存时间，默认7天
-log_retention = 7
-
-`
-	// 写入默认配置文件
-	err := os.WriteFile(configPath, []byte(defaultConfig), 0644)
-	if err != nil {
-		log.Fatalf("Failed to create default config file: %v", err)
+// applyConfig 切换到新的配置，只能在两次同步之间调用
+func (cf *CfDDNS) applyConfig(config Config) {
+	if cf.state == nil || config.StateDir != cf.Config.StateDir {
+		cf.state = loadState(config.StateDir)
 	}
-
-	log.Printf("Default config file created at %s. Please review and update it as needed.", configPath)
+	cf.api = &cloudflare.Client{
+		BaseURL:    cloudflare.DefaultBaseURL,
+		Token:      config.CFApiToken,
+		HTTPClient: httpClient,
+		OnResponse: metrics.cloudflareRequest,
+	}
+	cf.notifiers = buildNotifiers(config)
+	cf.Config = config
+	cf.status.reset(config.Records, cf.state)
+	cf.httpAPI.setToken(config.APIToken)
 }
 
-
 // 校验 IPv4 地址是否合法
 func isValidIPv4(ip string) bool {
 	return net.ParseIP(ip) != nil && regexp.MustCompile(`^(\d{1,3}\.){3}\d{1,3}$`).MatchString(ip)
@@ -159,160 +75,117 @@ func isValidIPv6(ip string) bool {
 	return net.ParseIP(ip) != nil && strings.Contains(ip, ":")
 }
 
-func (cf *CfDDNS) getIP1(ipType string) string {
-	url := cf.Config.GetIPv4URL
-	if ipType == "6" {
-		url = cf.Config.GetIPv6URL
-	}
-
-	resp, err := http.Get(url)
-	if err != nil || resp.StatusCode != 200 {
-		logMessage(fmt.Sprintf("Failed to retrieve IP address from %s, error: %v", url, err))
-		os.Exit(1)
-	}
-	defer resp.Body.Close()
-
-	ip, _ := io.ReadAll(resp.Body)
-	return string(ip)
-}
-
-func (cf *CfDDNS) getIP(ipType string) string {
-	url := cf.Config.GetIPv4URL
-	if ipType == "6" {
-		url = cf.Config.GetIPv6URL
-	}
-
-	retryCount := cf.Config.RetryCount // 获取配置中的重试次数
-	var lastError error
+// getIP 获取公网 IP，失败时按 retry_count/keep_retry 重试，ctx 取消时返回错误
+func (cf *CfDDNS) getIP(ctx context.Context, ipType string) (string, error) {
+	retryCount := max(cf.Config.RetryCount, 1) // 获取配置中的重试次数，至少尝试一次
+	lastError := errors.New("no attempts made")
 
 	for i := 0; i < retryCount; i++ {
-		// 一直重试
-		if cf.Config.KeepRetry ==1 {
-			i=0
+		// 一直重试，单次执行的命令不一直重试，避免 cron 等任务一直挂起
+		if cf.Config.KeepRetry == 1 && !cf.oneShot {
+			i = 0
 		}
-		resp, err := http.Get(url)
-		if err != nil {
-			lastError = err
-			logMessage(fmt.Sprintf("Attempt %d: Failed to retrieve IP address from %s. Error: %v", i+1, url, err))
-			time.Sleep(2 * time.Second)
-		} else if resp.StatusCode != 200 {
-			lastError = fmt.Errorf("unexpected status code: %d", resp.StatusCode)
-			logMessage(fmt.Sprintf("Attempt %d: Failed to retrieve IP address from %s. Status code: %d", i+1, url, resp.StatusCode))
-			time.Sleep(2 * time.Second)
-		} else {
-			defer resp.Body.Close()
-			ip, _ := io.ReadAll(resp.Body)
-			return string(ip)
+		ip, err := cf.detectIP(ctx, ipType)
+		if err == nil {
+			return ip, nil
+		}
+		if ctx.Err() != nil {
+			return "", ctx.Err()
 		}
+		lastError = err
+		slog.Warn("Failed to retrieve IP address", "ip_type", ipType, "attempt", i+1, "error", err)
 
 		// 如果是非最后一次重试，暂停一段时间
 		if i < retryCount-1 {
-			time.Sleep(2 * time.Second)
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(2 * time.Second):
+			}
 		}
 	}
 
-	// 如果所有重试都失败，发送 Telegram 通知并终止
-	// 发送 Telegram 通知
-	if cf.Config.Notify {
-		notifyMessage := fmt.Sprintf("Failed to retrieve IP address from %s after %d attempts. Last error: %v", url, retryCount, lastError)
-		logMessage(notifyMessage)
-		cf.tgMsg(notifyMessage)
-	}
-
-	os.Exit(1) // 可根据需求选择是否退出
-	return ""
+	// 如果所有重试都失败，发送通知
+	cf.notify(ctx, notify.Event{
+		Type:    notify.EventIPFailure,
+		Message: fmt.Sprintf("Failed to retrieve IPv%s address after %d attempts. Last error: %v", ipType, retryCount, lastError),
+		IPType:  ipType,
+		Error:   lastError.Error(),
+	})
+	slog.Error("Failed to retrieve IP address", "ip_type", ipType, "attempts", retryCount, "error", lastError)
+	return "", lastError
 }
 
-func (cf *CfDDNS) displayPublicIP() {
+func (cf *CfDDNS) displayPublicIP(ctx context.Context) {
 	// 获取 IPv4 地址
-	ipv4, ipv4Err := cf.getPublicIP(cf.Config.GetIPv4URL)
+	ipv4, ipv4Err := cf.detectIP(ctx, "4")
 	// 获取 IPv6 地址
-	ipv6, ipv6Err := cf.getPublicIP(cf.Config.GetIPv6URL)
+	ipv6, ipv6Err := cf.detectIP(ctx, "6")
 
 	// 输出结果
 	if ipv4Err == nil {
-		logMessage(fmt.Sprintf("IPv4 Address: %s", ipv4))
+		slog.Info("IPv4 Address", "ip", ipv4)
 	} else {
-		logMessage(fmt.Sprintf("Failed to get IPv4 Address: %v", ipv4Err))
+		slog.Error("Failed to get IPv4 Address", "error", ipv4Err)
 	}
 
 	if ipv6Err == nil {
-		logMessage(fmt.Sprintf("IPv6 Address: %s", ipv6))
+		slog.Info("IPv6 Address", "ip", ipv6)
 	} else {
-		logMessage(fmt.Sprintf("Failed to get IPv6 Address: %v", ipv6Err))
+		slog.Error("Failed to get IPv6 Address", "error", ipv6Err)
 	}
 
 	// 判断优先级
 	// if ipv4Err == nil && ipv6Err == nil {
-	// 	logMessage("Current network priority: IPv6 > IPv4")
+	// 	slog.Info("Current network priority: IPv6 > IPv4")
 	// } else if ipv6Err == nil {
-	// 	logMessage("Current network priority: IPv6")
+	// 	slog.Info("Current network priority: IPv6")
 	// } else if ipv4Err == nil {
-	// 	logMessage("Current network priority: IPv4")
+	// 	slog.Info("Current network priority: IPv4")
 	// } else {
-	// 	logMessage("No available network connection.")
+	// 	slog.Info("No available network connection.")
 	// }
 }
 
-// 获取公网 IP 地址的辅助函数
-func (cf *CfDDNS) getPublicIP(url string) (string, error) {
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch IP from %s: %v", url, err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("non-200 status code from %s: %d", url, resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %v", err)
-	}
-
-	return strings.TrimSpace(string(body)), nil
-}
-
 func displayCloudflareIPPriority() {
 	// 通过 Cloudflare 获取 IP 信息
 	url := "https://cloudflare.com/cdn-cgi/trace"
-	resp, err := http.Get(url)
+	resp, err := httpClient.Get(url)
 	if err != nil {
-		logMessage(fmt.Sprintf("Failed to fetch Cloudflare trace: %v", err))
+		slog.Error("Failed to fetch Cloudflare trace", "error", err)
 		return
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		logMessage(fmt.Sprintf("Non-200 status code from Cloudflare trace: %d", resp.StatusCode))
+		slog.Error("Non-200 status code from Cloudflare trace", "status", resp.StatusCode)
 		return
 	}
 
 	// 读取响应内容
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		logMessage(fmt.Sprintf("Failed to read Cloudflare trace response: %v", err))
+		slog.Error("Failed to read Cloudflare trace response", "error", err)
 		return
 	}
 
 	// 解析响应内容
 	traceInfo := parseCloudflareTrace(string(body))
 	if traceInfo == nil {
-		logMessage("Failed to parse Cloudflare trace response.")
+		slog.Error("Failed to parse Cloudflare trace response.")
 		return
 	}
 
 	// 输出 IP 信息和优先级
 	ip := traceInfo["ip"]
-	// logMessage(fmt.Sprintf("Detected Public IP: %s", ip))
+	// slog.Info(fmt.Sprintf("Detected Public IP: %s", ip))
 
 	if isIPv6(ip) {
-		logMessage("Current network priority: IPv6")
+		slog.Info("Current network priority: IPv6")
 	} else if isIPv4(ip) {
-		logMessage("Current network priority: IPv4")
+		slog.Info("Current network priority: IPv4")
 	} else {
-		logMessage("Unknown IP type. Unable to determine network priority.")
+		slog.Warn("Unknown IP type. Unable to determine network priority.", "ip", ip)
 	}
 }
 
@@ -339,63 +212,54 @@ func isIPv4(ip string) bool {
 	return strings.Contains(ip, ".") && net.ParseIP(ip) != nil
 }
 
-
-
-func (cf *CfDDNS) getCurrentDNSRecordIP(ipType string) map[string]string {
-	result := make(map[string]string)
-
-	// 如果 CF_IP_TYPE 是 46，同时获取 IPv4 和 IPv6
-	ipTypes := []string{ipType}
+// ipTypesOf 将 ip_type 展开为需要处理的协议族列表
+func ipTypesOf(ipType string) []string {
+	// 如果 ip_type 是 46，同时处理 IPv4 和 IPv6
 	if ipType == "46" {
-		ipTypes = []string{"4", "6"}
+		return []string{"4", "6"}
 	}
+	return []string{ipType}
+}
 
-	for _, t := range ipTypes {
-		recordType := "A"
-		if t == "6" {
-			recordType = "AAAA"
-		}
+// recordTypeOf 根据协议族返回对应的 DNS 记录类型
+func recordTypeOf(ipType string) string {
+	if ipType == "6" {
+		return "AAAA"
+	}
+	return "A"
+}
 
-		// 构建请求头
-		headers := map[string]string{
-			"Authorization": fmt.Sprintf("Bearer %s", cf.Config.CFApiToken),
-			"Content-Type":  "application/json",
-		}
+// lookupDNSRecords 查询记录在 Cloudflare 上的所有同名同类型记录
+func (cf *CfDDNS) lookupDNSRecords(ctx context.Context, rec RecordConfig, ipType string) ([]cloudflare.DNSRecord, error) {
+	records, err := cf.api.ListDNSRecords(ctx, rec.ZoneID, cloudflare.ListDNSRecordsParams{
+		Name: rec.Name,
+		Type: recordTypeOf(ipType),
+	})
+	if err != nil {
+		cf.forgetZone(rec, err)
+	}
+	return records, err
+}
 
-		// 获取当前 DNS 记录
-		url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records", cf.Config.CFZoneID)
-		req, _ := http.NewRequest("GET", url, nil)
-		for k, v := range headers {
-			req.Header.Set(k, v)
-		}
-		q := req.URL.Query()
-		q.Add("name", cf.Config.CFRecordName)
-		q.Add("type", recordType)
-		req.URL.RawQuery = q.Encode()
+func (cf *CfDDNS) getCurrentDNSRecordIP(ctx context.Context, rec RecordConfig, ipType string) map[string]string {
+	result := make(map[string]string)
 
-		resp, err := http.DefaultClient.Do(req)
+	for _, t := range ipTypesOf(ipType) {
+		// 获取当前 DNS 记录
+		records, err := cf.lookupDNSRecords(ctx, rec, t)
 		if err != nil {
-			logMessage(fmt.Sprintf("Error fetching DNS record (%s): %v", t, err))
+			recordLogger(rec, t).Error("Error fetching DNS record", "error", err)
 			result[t] = "Error fetching record"
 			continue
 		}
-		defer resp.Body.Close()
 
-		var apiResponse map[string]interface{}
-		if err := json.NewDecoder(resp.Body).Decode(&apiResponse); err != nil {
-			logMessage(fmt.Sprintf("Error decoding DNS record response (%s): %v", t, err))
-			result[t] = "Error decoding record"
-			continue
-		}
-
-		// 获取 IP 地址
-		if records, ok := apiResponse["result"].([]interface{}); ok && len(records) > 0 {
-			record := records[0].(map[string]interface{})
-			if content, ok := record["content"].(string); ok {
-				result[t] = content
-			} else {
-				result[t] = "No content found"
+		// 获取 IP 地址，存在多条记录时全部列出
+		if len(records) > 0 {
+			ips := make([]string, 0, len(records))
+			for _, r := range records {
+				ips = append(ips, r.Content)
 			}
+			result[t] = strings.Join(ips, ", ")
 		} else {
 			result[t] = "Record not found"
 		}
@@ -404,210 +268,378 @@ func (cf *CfDDNS) getCurrentDNSRecordIP(ipType string) map[string]string {
 	return result
 }
 
-func (cf *CfDDNS) updateDNSRecord(ipType string) {
-	ipTypes := []string{ipType}
+// recordPatch 计算将 existing 同步为期望状态需要修改的字段
+// ttl、proxied、comment、tags 只有在配置中显式设置时才会被强制修改
+func recordPatch(rec RecordConfig, existing cloudflare.DNSRecord, ip string) cloudflare.DNSRecordPatch {
+	var patch cloudflare.DNSRecordPatch
+	if existing.Content != ip {
+		patch.Content = ip
+	}
+	if rec.TTL != nil && *rec.TTL != existing.TTL {
+		patch.TTL = rec.TTL
+	}
+	if rec.Proxied != nil && *rec.Proxied != existing.Proxied {
+		patch.Proxied = rec.Proxied
+	}
+	if rec.Comment != nil && *rec.Comment != existing.Comment {
+		patch.Comment = rec.Comment
+	}
+	if rec.Tags != nil && !slices.Equal(*rec.Tags, existing.Tags) {
+		patch.Tags = rec.Tags
+	}
+	return patch
+}
 
-	// 如果 CF_IP_TYPE 为 46，同时更新 IPv4 和 IPv6
-	if ipType == "46" {
-		ipTypes = []string{"4", "6"}
-	}
-	// 获取当前的 DNS 记录 IP（IPv4 和 IPv6）
-	currentIPs := cf.getCurrentDNSRecordIP(ipType)
-	for _, t := range ipTypes {
-		ip := cf.getIP(t)
-		currentIP, ok := currentIPs[t]
-		if !ok {
-			currentIP = "Unknown" // 如果返回的 map 中不存在对应类型，设置为未知
-		}
+// updateDNSRecord 对所有配置的记录执行一次同步
+// ipType 为空时使用每条记录自身的 ip_type，否则统一使用 ipType
+// ctx 取消后不再处理剩余的记录，进行中的请求最多再等待 shutdown_timeout 秒
+func (cf *CfDDNS) updateDNSRecord(ctx context.Context, ipType string) cycleResult {
+	opCtx, cancel := graceContext(ctx, time.Duration(cf.Config.ShutdownTimeout)*time.Second)
+	defer cancel()
+
+	defer cf.logAPIStats(cf.api.Stats())
+
+	cf.resolveZoneIDs(opCtx)
 
-		if ip == currentIP {
-			logMessage(fmt.Sprintf("IPv%s: %s has not changed, no update needed.", t, currentIP))
+	// 每个周期内每个协议族只获取一次公网 IP，获取失败的协议族跳过
+	publicIPs := make(map[string]string)
+	ipFailed := make(map[string]error)
+	var result cycleResult
+
+	for _, rec := range cf.Config.Records {
+		if ctx.Err() != nil {
+			slog.Info("Shutdown requested, skipping remaining records.")
+			return result
+		}
+		// zone 查找失败的记录已记录日志，跳过
+		if rec.ZoneID == "" {
+			for _, t := range ipTypesOf(rec.IPType) {
+				cf.status.checked(rec, t, "", syncFailed, errZoneNotFound, cf.Config.DryRun)
+			}
+			result.add(syncFailed)
 			continue
 		}
-		updateResult := cf.updateDNSRecordHandle(t, cf.Config.CFRecordName, ip)
 
-		// 发送 Telegram 通知
-		if cf.Config.Notify {
+		recIPType := rec.IPType
+		if ipType != "" {
+			recIPType = ipType
+		}
 
-			if updateResult {
-				notificationMessage := fmt.Sprintf("IPv%s DNS record for %s updated from %s to %s successfully.", t, cf.Config.CFRecordName, currentIP, ip)
-				cf.tgMsg(notificationMessage)
-			} else {
-				notificationMessage := fmt.Sprintf("IPv%s DNS record for %s updated from %s to %s failed.", t, cf.Config.CFRecordName, currentIP, ip)
-				cf.tgMsg(notificationMessage)
+		for _, t := range ipTypesOf(recIPType) {
+			if err, failed := ipFailed[t]; failed {
+				cf.status.checked(rec, t, "", syncFailed, err, cf.Config.DryRun)
+				continue
+			}
+			ip, ok := publicIPs[t]
+			if !ok {
+				var err error
+				ip, err = cf.getIP(opCtx, t)
+				if ctx.Err() != nil {
+					return result
+				}
+				if err != nil {
+					err = fmt.Errorf("failed to detect IPv%s address: %w", t, err)
+					ipFailed[t] = err
+					result.IPFailed = true
+					cf.status.checked(rec, t, "", syncFailed, err, cf.Config.DryRun)
+					continue
+				}
+				publicIPs[t] = ip
 			}
+			outcome, err := cf.syncRecord(opCtx, rec, t, ip)
+			cf.status.checked(rec, t, ip, outcome, err, cf.Config.DryRun)
+			result.add(outcome)
 		}
 	}
-
+	return result
 }
 
-func (cf *CfDDNS) updateDNSRecordWithIP(ipType, ip string) {
-	cf.updateDNSRecordHandle(ipType, cf.Config.CFRecordName, ip)
+// logAPIStats 输出自 prev 以来的 Cloudflare 请求计数，出现重试或失败时使用 Warn 级别
+func (cf *CfDDNS) logAPIStats(prev cloudflare.Stats) {
+	d := cf.api.Stats().Sub(prev)
+	if d.Requests == 0 {
+		return
+	}
+	level := slog.LevelInfo
+	if d.Retries > 0 || d.RateLimited > 0 || d.ServerErrors > 0 || d.NetworkErrors > 0 || d.PermanentErrors > 0 {
+		level = slog.LevelWarn
+	}
+	slog.Log(context.Background(), level, "Cloudflare API stats",
+		"requests", d.Requests, "retries", d.Retries, "rate_limited", d.RateLimited,
+		"server_errors", d.ServerErrors, "network_errors", d.NetworkErrors, "permanent_errors", d.PermanentErrors)
+	if d.PermanentErrors > 0 {
+		slog.Warn("Some Cloudflare requests failed permanently, check the API token permissions and the record settings.")
+	}
 }
 
-func (cf *CfDDNS) updateDNSRecordHandle(ipType, domainName string, ip string) bool {
-	// 构建1个公共的DNS记录更新函数
-	recordType := "A"
-	if ipType == "6" {
-		recordType = "AAAA"
+// syncRecord 将单条记录同步为 ip
+// 本地缓存未过期且记录设置未变化时，只有检测到的 IP 与缓存不同才会访问 Cloudflare
+func (cf *CfDDNS) syncRecord(ctx context.Context, rec RecordConfig, ipType, ip string) (syncOutcome, error) {
+	logger := recordLogger(rec, ipType)
+	key := stateKey(rec, ipType)
+
+	// 需要同步的记录，为空时表示记录不存在
+	var targets []cloudflare.DNSRecord
+	cached, hasCache := cf.state.get(key)
+	// 演练模式不使用缓存，查询记录的当前值用于输出计划
+	// 重新加载后 ttl、proxied 等设置发生变化时也不使用缓存，以便立即同步新的设置
+	fromCache := !cf.Config.DryRun && hasCache && cached.Settings == recordSettings(rec) &&
+		time.Since(cached.SyncedAt) < time.Duration(cf.Config.ResyncInterval)*time.Second
+	if fromCache && cached.IP == ip {
+		logger.Info("IP has not changed, no update needed.", "ip", ip)
+		return syncUnchanged, nil
 	}
-
-	// 构建请求头
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %s", cf.Config.CFApiToken),
-		"Content-Type":  "application/json",
+	if fromCache {
+		// IP 变化时直接使用缓存的记录 ID 更新，省去一次查询
+		for _, id := range cached.recordIDs() {
+			targets = append(targets, cloudflare.DNSRecord{ID: id, Content: cached.IP})
+		}
+	} else {
+		// 获取当前的 DNS 记录
+		records, err := cf.lookupDNSRecords(ctx, rec, ipType)
+		if err != nil {
+			logger.Error("Error fetching DNS record", "error", err)
+			cf.notifyUpdateFailure(ctx, rec, ipType, rec.Name, "Error fetching record", "", ip, err)
+			return syncFailed, err
+		}
+		targets, err = cf.handleDuplicates(ctx, rec, ipType, records, ip)
+		if err != nil {
+			// duplicates = "fail" 时 handleDuplicates 已经发送过重复记录通知
+			if !errors.As(err, new(*duplicatesError)) {
+				cf.notifyUpdateFailure(ctx, rec, ipType, fmt.Sprintf("%s (%d records)", rec.Name, len(records)), records[0].Content, records[0].Content, ip, err)
+			}
+			return syncFailed, err
+		}
+		if upToDate(rec, targets, ip) {
+			logger.Info("IP has not changed, no update needed.", "ip", ip)
+			cf.state.set(key, syncedState(rec, targets, ip))
+			return syncUnchanged, nil
+		}
 	}
 
-	// 获取 DNS 记录 ID
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records", cf.Config.CFZoneID)
-	req, _ := http.NewRequest("GET", url, nil)
-	for k, v := range headers {
-		req.Header.Set(k, v)
+	var err error
+	applied := 0
+	if len(targets) == 0 {
+		err = cf.applyDNSRecord(ctx, rec, ipType, nil, false, ip)
 	}
-	q := req.URL.Query()
-	q.Add("name", domainName)
-	q.Add("type", recordType)
-	req.URL.RawQuery = q.Encode()
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		logMessage(fmt.Sprintf("Error fetching DNS record: %v", err))
-		return false
+	for i := range targets {
+		if applyErr := cf.applyDNSRecord(ctx, rec, ipType, &targets[i], fromCache, ip); applyErr != nil {
+			err = applyErr
+		} else {
+			applied++
+		}
 	}
-	defer resp.Body.Close()
-
-	var apiResponse map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&apiResponse)
-
-	var recordID string
-	if records, ok := apiResponse["result"].([]interface{}); ok && len(records) > 0 {
-		record := records[0].(map[string]interface{})
-		recordID = record["id"].(string)
-		// currentIP = record["content"].(string)
-	} else if cf.Config.AddRecordIfMissing {
-		// 如果记录不存在并且配置允许添加
-		logMessage(fmt.Sprintf("DNS record IPv%s for %s not found. Adding a new record...", ipType, cf.Config.CFRecordName))
-		recordID = cf.addDNSRecord(recordType, ip)
-	}else {
-		logMessage(fmt.Sprintf("DNS IPv%s record for %s not found.", ipType, domainName))
-		return false
+	if err != nil && fromCache && cloudflare.IsNotFound(err) {
+		// 缓存的记录已在 Cloudflare 上被删除，清除缓存后重新查询
+		logger.Warn("Cached DNS record no longer exists, resyncing.", "record_ids", cached.recordIDs())
+		cf.state.forget(key)
+		outcome, err := cf.syncRecord(ctx, rec, ipType, ip)
+		if err == nil && outcome == syncUnchanged && applied > 0 {
+			// 其余缓存的记录在重新查询前已经更新
+			name := rec.Name
+			if applied > 1 {
+				name = fmt.Sprintf("%s (%d records)", rec.Name, applied)
+			}
+			cf.notifyUpdateSuccess(ctx, rec, ipType, name, cached.IP, cached.IP, ip)
+			outcome = syncUpdated
+		}
+		return outcome, err
 	}
-
-	if recordID == "" {
-		logMessage(fmt.Sprintf("Failed to find or create IPv%s DNS record for %s.", ipType, cf.Config.CFRecordName))
-		return false
+	if len(targets) > 1 && !cf.Config.DryRun {
+		// applyDNSRecord 只缓存单条记录，多条记录时缓存全部 ID，部分失败时清除缓存以便下次重新查询
+		if err == nil {
+			cf.state.set(key, syncedState(rec, targets, ip))
+		} else {
+			cf.state.forget(key)
+		}
 	}
 
-	// 更新 DNS 记录
-	data := map[string]interface{}{
-		"type":    recordType,
-		"name":    domainName,
-		"content": ip,
-		"ttl":     1800,
-		"proxied": false,
+	currentIP, oldIP := "Record not found", ""
+	if len(targets) > 0 {
+		currentIP, oldIP = targets[0].Content, targets[0].Content
 	}
-
-	body, _ := json.Marshal(data)
-	updateURL := fmt.Sprintf("%s/%s", url, recordID)
-	updateReq, _ := http.NewRequest("PUT", updateURL, bytes.NewBuffer(body))
-	for k, v := range headers {
-		updateReq.Header.Set(k, v)
+	name := rec.Name
+	if len(targets) > 1 {
+		name = fmt.Sprintf("%s (%d records)", rec.Name, len(targets))
 	}
 
-	updateResp, err := http.DefaultClient.Do(updateReq)
-	if err != nil || updateResp.StatusCode != 200 {
-		logMessage(fmt.Sprintf("Failed to update DNS record: %v", err))
-		return false
+	// 发送通知
+	if err != nil {
+		cf.notifyUpdateFailure(ctx, rec, ipType, name, currentIP, oldIP, ip, err)
+		return syncFailed, err
 	}
-	logMessage(fmt.Sprintf("DNS IPv%s record for %s updated to %s successfully.", ipType, domainName, ip))
-	return true
-	// } else {
-	// 	logMessage(fmt.Sprintf("DNS IPv%s record for %s not found.", ipType, domainName))
-	// 	return false
-	// }
+	cf.notifyUpdateSuccess(ctx, rec, ipType, name, currentIP, oldIP, ip)
+	return syncUpdated, nil
 }
 
-// 添加 DNS 记录的辅助函数
-func (cf *CfDDNS) addDNSRecord(recordType, ip string) string {
-	headers := map[string]string{
-		"Authorization": fmt.Sprintf("Bearer %s", cf.Config.CFApiToken),
-		"Content-Type":  "application/json",
+// notifyUpdateSuccess 发送记录同步成功的通知，参数与 notifyUpdateFailure 相同
+func (cf *CfDDNS) notifyUpdateSuccess(ctx context.Context, rec RecordConfig, ipType, name, currentIP, oldIP, ip string) {
+	cf.notify(ctx, notify.Event{
+		Type:    notify.EventUpdateSuccess,
+		Message: fmt.Sprintf("IPv%s DNS record for %s updated from %s to %s successfully.", ipType, name, currentIP, ip),
+		Record:  rec.Name,
+		IPType:  ipType,
+		OldIP:   oldIP,
+		NewIP:   ip,
+		Success: true,
+	})
+}
+
+// notifyUpdateFailure 发送记录同步失败的通知，name 和 currentIP 用于消息文本，oldIP 为空表示记录不存在或未知
+func (cf *CfDDNS) notifyUpdateFailure(ctx context.Context, rec RecordConfig, ipType, name, currentIP, oldIP, ip string, err error) {
+	// 退出时被取消的请求不算同步失败
+	if ctx.Err() != nil {
+		return
 	}
+	cf.notify(ctx, notify.Event{
+		Type:    notify.EventUpdateFailure,
+		Message: fmt.Sprintf("IPv%s DNS record for %s updated from %s to %s failed.", ipType, name, currentIP, ip),
+		Record:  rec.Name,
+		IPType:  ipType,
+		OldIP:   oldIP,
+		NewIP:   ip,
+		Error:   err.Error(),
+	})
+}
 
-	url := fmt.Sprintf("https://api.cloudflare.com/client/v4/zones/%s/dns_records", cf.Config.CFZoneID)
+// upToDate 判断查询到的记录是否都已经是期望的状态，targets 为空时返回 false
+func upToDate(rec RecordConfig, targets []cloudflare.DNSRecord, ip string) bool {
+	return len(targets) > 0 && !slices.ContainsFunc(targets, func(r cloudflare.DNSRecord) bool {
+		return !recordPatch(rec, r, ip).IsEmpty()
+	})
+}
 
-	data := map[string]interface{}{
-		"type":    recordType,
-		"name":    cf.Config.CFRecordName,
-		"content": ip,
-		"ttl":     1800,
-		"proxied": false,
-	}
+// updateDNSRecordWithIP 将所有配置的记录更新为指定 IP
+func (cf *CfDDNS) updateDNSRecordWithIP(ctx context.Context, ipType, ip string) cycleResult {
+	defer cf.logAPIStats(cf.api.Stats())
 
-	body, _ := json.Marshal(data)
-	req, _ := http.NewRequest("POST", url, bytes.NewBuffer(body))
-	for k, v := range headers {
-		req.Header.Set(k, v)
+	var result cycleResult
+	cf.resolveZoneIDs(ctx)
+	for _, rec := range cf.Config.Records {
+		if rec.ZoneID == "" {
+			result.add(syncFailed)
+			continue
+		}
+		result.add(cf.updateDNSRecordHandle(ctx, rec, ipType, ip))
 	}
+	return result
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil || resp.StatusCode != 200 {
-		logMessage(fmt.Sprintf("Failed to create DNS record (%s): %v", recordType, err))
-		return ""
+func (cf *CfDDNS) updateDNSRecordHandle(ctx context.Context, rec RecordConfig, ipType string, ip string) syncOutcome {
+	// 获取 DNS 记录 ID
+	records, err := cf.lookupDNSRecords(ctx, rec, ipType)
+	if err != nil {
+		recordLogger(rec, ipType).Error("Error fetching DNS record", "error", err)
+		return syncFailed
 	}
-	defer resp.Body.Close()
-
-	var apiResponse map[string]interface{}
-	json.NewDecoder(resp.Body).Decode(&apiResponse)
-
-	if record, ok := apiResponse["result"].(map[string]interface{}); ok {
-		if recordID, ok := record["id"].(string); ok {
-			logMessage(fmt.Sprintf("Successfully created DNS record (%s) for %s.", recordType, cf.Config.CFRecordName))
-			return recordID
+	targets, err := cf.handleDuplicates(ctx, rec, ipType, records, ip)
+	if err != nil {
+		return syncFailed
+	}
+	if upToDate(rec, targets, ip) {
+		recordLogger(rec, ipType).Info("DNS record is already up to date, no update needed.", "ip", ip)
+		return syncUnchanged
+	}
+	if len(targets) == 0 {
+		if cf.applyDNSRecord(ctx, rec, ipType, nil, false, ip) != nil {
+			return syncFailed
 		}
+		return syncUpdated
 	}
-
-	logMessage(fmt.Sprintf("Failed to parse response when creating DNS record (%s).", recordType))
-	return ""
+	outcome := syncUpdated
+	for i := range targets {
+		if cf.applyDNSRecord(ctx, rec, ipType, &targets[i], false, ip) != nil {
+			outcome = syncFailed
+		}
+	}
+	return outcome
 }
 
-func (cf *CfDDNS) tgMsg(message string) {
-	// 判断是否设置了自定义的 Telegram API URL
-	// baseURL := "https://api.telegram.org"
-	// if cf.Config.TgApiUrl != "" {
-	// 	baseURL = cf.Config.TgApiUrl
-	// }
-	// baseURL := cf.Config.TgApiUrl
-
-	// 构造完整的请求 URL
-	url := fmt.Sprintf("%s/bot%s/sendMessage", cf.Config.TgApiUrl, cf.Config.TGToken)
-	// logMessage(url)
-
-	// url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", cf.Config.TGToken)
-
-	data := map[string]interface{}{
-		"chat_id":                  cf.Config.TGChatID,
-		"text":                     message,
-		"disable_web_page_preview": true,
+// applyDNSRecord 将记录同步为 ip，existing 为 nil 时按配置决定是否新建
+// cached 表示 existing 来自本地缓存，此时记录不存在只返回错误，由调用方重新查询
+// 成功后将记录 ID 和 IP 写入本地缓存
+func (cf *CfDDNS) applyDNSRecord(ctx context.Context, rec RecordConfig, ipType string, existing *cloudflare.DNSRecord, cached bool, ip string) error {
+	logger := recordLogger(rec, ipType)
+	key := stateKey(rec, ipType)
+	if existing == nil {
+		if !cf.Config.AddRecordIfMissing {
+			logger.Warn("DNS record not found.")
+			return fmt.Errorf("DNS record %s (%s) not found", rec.Name, recordTypeOf(ipType))
+		}
+		// 如果记录不存在并且配置允许添加
+		logger.Info("DNS record not found. Adding a new record...", "new_ip", ip)
+		if cf.Config.DryRun {
+			printPlan("create", nil, newDNSRecord(rec, recordTypeOf(ipType), ip))
+			return nil
+		}
+		recordID, err := cf.addDNSRecord(ctx, rec, recordTypeOf(ipType), ip)
+		metrics.dnsUpdates.inc(recordTypeOf(ipType), "create", resultLabel(err))
+		if err != nil {
+			return err
+		}
+		cf.state.set(key, newRecordState(rec, recordID, ip))
+		return nil
 	}
 
-	body, _ := json.Marshal(data)
+	patch := recordPatch(rec, *existing, ip)
+	if patch.IsEmpty() {
+		logger.Info("DNS record is already up to date, no update needed.", "ip", ip)
+		cf.state.set(key, newRecordState(rec, existing.ID, ip))
+		return nil
+	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	// 只修改内容及显式配置的字段，保留记录上的其他设置
+	logger = logger.With("old_ip", existing.Content, "new_ip", ip)
+	if cf.Config.DryRun {
+		printPlan("update", existing, applyPatch(*existing, patch))
+		return nil
+	}
+	_, err := cf.api.PatchDNSRecord(ctx, rec.ZoneID, existing.ID, patch)
+	metrics.dnsUpdates.inc(recordTypeOf(ipType), "update", resultLabel(err))
 	if err != nil {
-		logMessage(fmt.Sprintf("Failed to create Telegram request: %v", err))
-		return
+		if !cached || !cloudflare.IsNotFound(err) {
+			logger.Error("Failed to update DNS record", "error", err)
+		}
+		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	logger.Info("DNS record updated successfully.")
+	cf.state.set(key, newRecordState(rec, existing.ID, ip))
+	return nil
+}
 
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil || resp.StatusCode != 200 {
-		logMessage(fmt.Sprintf("Failed to send Telegram message: %v, status code: %d", err, resp.StatusCode))
-		return
+// newDNSRecord 根据记录配置生成新建的 DNS 记录
+func newDNSRecord(rec RecordConfig, recordType, ip string) cloudflare.DNSRecord {
+	record := cloudflare.DNSRecord{
+		Type:    recordType,
+		Name:    rec.Name,
+		Content: ip,
+	}
+	// 未配置的字段使用 Cloudflare 的默认值
+	if rec.TTL != nil {
+		record.TTL = *rec.TTL
+	}
+	if rec.Proxied != nil {
+		record.Proxied = *rec.Proxied
+	}
+	if rec.Comment != nil {
+		record.Comment = *rec.Comment
+	}
+	if rec.Tags != nil {
+		record.Tags = *rec.Tags
+	}
+	return record
+}
+
+// 添加 DNS 记录的辅助函数
+func (cf *CfDDNS) addDNSRecord(ctx context.Context, rec RecordConfig, recordType, ip string) (string, error) {
+	created, err := cf.api.CreateDNSRecord(ctx, rec.ZoneID, newDNSRecord(rec, recordType, ip))
+	if err != nil {
+		slog.Error("Failed to create DNS record", "zone", rec.ZoneID, "record", rec.Name, "type", recordType, "new_ip", ip, "error", err)
+		return "", err
 	}
-	defer resp.Body.Close()
 
-	logMessage("Telegram notification sent successfully.")
+	slog.Info("Successfully created DNS record.", "zone", rec.ZoneID, "record", rec.Name, "type", recordType, "new_ip", ip)
+	return created.ID, nil
 }
 
 // setupService 配置程序为系统服务
@@ -618,7 +650,7 @@ func setupService(serviceName string) {
 	case "linux":
 		setupLinuxService(serviceName)
 	default:
-		logMessage("Service setup is not supported on this operating system.")
+		slog.Error("Service setup is not supported on this operating system.", "os", runtime.GOOS)
 	}
 }
 
@@ -626,14 +658,14 @@ func setupService(serviceName string) {
 // func setupWindowsService(serviceName string) {
 // 	m, err := mgr.Connect()
 // 	if err != nil {
-// 		logMessage(fmt.Sprintf("Failed to connect to Windows service manager: %v", err))
+// 		slog.Info(fmt.Sprintf("Failed to connect to Windows service manager: %v", err))
 // 		return
 // 	}
 // 	defer m.Disconnect()
 
 // 	exePath, err := os.Executable()
 // 	if err != nil {
-// 		logMessage(fmt.Sprintf("Failed to get executable path: %v", err))
+// 		slog.Info(fmt.Sprintf("Failed to get executable path: %v", err))
 // 		return
 // 	}
 
@@ -641,19 +673,19 @@ func setupService(serviceName string) {
 // 		StartType: mgr.StartAutomatic,
 // 	})
 // 	if err != nil {
-// 		logMessage(fmt.Sprintf("Failed to create Windows service: %v", err))
+// 		slog.Info(fmt.Sprintf("Failed to create Windows service: %v", err))
 // 		return
 // 	}
 // 	defer service.Close()
 
-// 	logMessage(fmt.Sprintf("Windows service '%s' created successfully.", serviceName))
+// 	slog.Info(fmt.Sprintf("Windows service '%s' created successfully.", serviceName))
 // }
 
 // setupLinuxService 配置 Linux 服务
 func setupLinuxService(serviceName string) {
 	exePath, err := os.Executable()
 	if err != nil {
-		logMessage(fmt.Sprintf("Failed to get executable path: %v", err))
+		slog.Error("Failed to get executable path", "error", err)
 		return
 	}
 
@@ -673,7 +705,7 @@ WantedBy=multi-user.target
 	content := fmt.Sprintf(serviceContent, exePath)
 
 	if err := os.WriteFile(serviceFile, []byte(content), 0644); err != nil {
-		logMessage(fmt.Sprintf("Failed to write service file: %v", err))
+		slog.Error("Failed to write service file", "path", serviceFile, "error", err)
 		return
 	}
 
@@ -686,12 +718,12 @@ WantedBy=multi-user.target
 
 	for _, cmd := range cmds {
 		if err := exec.Command(cmd[0], cmd[1:]...).Run(); err != nil {
-			logMessage(fmt.Sprintf("Failed to execute '%s %v': %v", cmd[0], cmd[1:], err))
+			slog.Error("Failed to execute command", "command", strings.Join(cmd, " "), "error", err)
 			return
 		}
 	}
 
-	logMessage(fmt.Sprintf("Linux service '%s' created and started successfully.", serviceName))
+	slog.Info("Linux service created and started successfully.", "service", serviceName)
 }
 
 // removeService 移除系统服务
@@ -702,7 +734,7 @@ func removeService(serviceName string) {
 	case "linux":
 		removeLinuxService(serviceName)
 	default:
-		logMessage("Service removal is not supported on this operating system.")
+		slog.Error("Service removal is not supported on this operating system.", "os", runtime.GOOS)
 	}
 }
 
@@ -710,14 +742,14 @@ func removeService(serviceName string) {
 // func removeWindowsService(serviceName string) {
 // 	m, err := mgr.Connect()
 // 	if err != nil {
-// 		logMessage(fmt.Sprintf("Failed to connect to Windows service manager: %v", err))
+// 		slog.Info(fmt.Sprintf("Failed to connect to Windows service manager: %v", err))
 // 		return
 // 	}
 // 	defer m.Disconnect()
 
 // 	service, err := m.OpenService(serviceName)
 // 	if err != nil {
-// 		logMessage(fmt.Sprintf("Service '%s' not found: %v", serviceName, err))
+// 		slog.Info(fmt.Sprintf("Service '%s' not found: %v", serviceName, err))
 // 		return
 // 	}
 // 	defer service.Close()
@@ -725,15 +757,15 @@ func removeService(serviceName string) {
 // 	// 确认服务是否由本程序创建（简单示例，可扩展为更复杂校验）
 // 	config, err := service.Config()
 // 	if err != nil {
-// 		logMessage(fmt.Sprintf("Failed to get service config: %v", err))
+// 		slog.Info(fmt.Sprintf("Failed to get service config: %v", err))
 // 		return
 // 	}
 
 // 	if !strings.Contains(config.BinaryPathName, "cfddns") {
-// 		logMessage(fmt.Sprintf("Service '%s' does not appear to be created by this program.", serviceName))
-// 		logMessage(fmt.Sprintf("Service executable: %s", config.BinaryPathName))
+// 		slog.Info(fmt.Sprintf("Service '%s' does not appear to be created by this program.", serviceName))
+// 		slog.Info(fmt.Sprintf("Service executable: %s", config.BinaryPathName))
 // 		if !confirm("Do you want to remove this service anyway? (y/N)") {
-// 			logMessage("Service removal canceled.")
+// 			slog.Info("Service removal canceled.")
 // 			return
 // 		}
 // 	}
@@ -741,11 +773,11 @@ func removeService(serviceName string) {
 // 	// 删除服务
 // 	err = service.Delete()
 // 	if err != nil {
-// 		logMessage(fmt.Sprintf("Failed to delete service '%s': %v", serviceName, err))
+// 		slog.Info(fmt.Sprintf("Failed to delete service '%s': %v", serviceName, err))
 // 		return
 // 	}
 
-// 	logMessage(fmt.Sprintf("Service '%s' removed successfully.", serviceName))
+// 	slog.Info(fmt.Sprintf("Service '%s' removed successfully.", serviceName))
 // }
 
 // removeLinuxService 移除 Linux 服务
@@ -754,20 +786,20 @@ func removeLinuxService(serviceName string) {
 
 	// 检查服务文件是否存在
 	if _, err := os.Stat(serviceFile); os.IsNotExist(err) {
-		logMessage(fmt.Sprintf("Service file '%s' not found.", serviceFile))
+		slog.Error("Service file not found.", "path", serviceFile)
 		return
 	}
 
 	// 显示服务文件内容并确认
 	content, err := os.ReadFile(serviceFile)
 	if err != nil {
-		logMessage(fmt.Sprintf("Failed to read service file '%s': %v", serviceFile, err))
+		slog.Error("Failed to read service file", "path", serviceFile, "error", err)
 		return
 	}
-	logMessage(fmt.Sprintf("Service file content:\n%s", string(content)))
+	fmt.Printf("Service file content:\n%s\n", string(content))
 
 	if !confirm("Do you want to remove this service? (y/N)") {
-		logMessage("Service removal canceled.")
+		slog.Info("Service removal canceled.")
 		return
 	}
 
@@ -781,12 +813,12 @@ func removeLinuxService(serviceName string) {
 
 	for _, cmd := range cmds {
 		if err := exec.Command(cmd[0], cmd[1:]...).Run(); err != nil {
-			logMessage(fmt.Sprintf("Failed to execute '%s %v': %v", cmd[0], cmd[1:], err))
+			slog.Error("Failed to execute command", "command", strings.Join(cmd, " "), "error", err)
 			return
 		}
 	}
 
-	logMessage(fmt.Sprintf("Linux service '%s' removed successfully.", serviceName))
+	slog.Info("Linux service removed successfully.", "service", serviceName)
 }
 
 // confirm 显示确认提示
@@ -821,15 +853,26 @@ https://github.com/aircross/cfddns
           |||||   ||||| |||||||||||    |||||||||||    ||||||      |    ||||||    
 
 Usage:
-  cfddns [command] [arguments]
+  cfddns [options] [command] [arguments]
+
+Options:
+  --config <path>     Path to the config file. Default: conf.toml.
+  --dry-run           Detect IPs and query Cloudflare, but only print the planned changes.
+  --notify-dry-run    Send notifications in dry-run mode (suppressed by default).
+  --<key> <value>     Override any config key, '_' written as '-', e.g. --interval 300, --debug.
+                      Lists and tables use TOML syntax, e.g. --records '[{ name = "a.example.com" }]'.
 
 Commands:
-  tgtest              Send a test message to the configured Telegram chat.
+  tgtest              Send a test message to every configured Telegram notifier.
+  notify-test [name...]
+                      Send a test message to the named notifiers, or to all notifiers.
   ip                  Query and display the current IP and network priority.
   now                 Query and display the current DNS record IP for the domain.
   v4 <IPv4>           Update the domain's IPv4 DNS record to the specified IPv4 address.
   v6 <IPv6>           Update the domain's IPv6 DNS record to the specified IPv6 address.
   v46                 Update the domain's IPv4 and IPv6 DNS record to the wan IP address.
+  once                Sync all records once and exit, for cron or systemd timers.
+  check               Validate the configuration and verify the Cloudflare API token.
   v, ver, version     Show the program version.
   h, help             Show this help message and exit.
 Todo:
@@ -840,6 +883,8 @@ Todo:
 Examples:
   cfddns              Run the program with the default configuration (dynamic DNS update).
   cfddns tgtest       Send a test message via Telegram.
+  cfddns notify-test ops
+                      Send a test message via the notifier named 'ops'.
   cfddns ip           Display the current IP address and network priority.
   cfddns now          Display the current IP address associated with the DNS record.
   cfddns v4           Update the domain's A record to wan IPv4 IP.
@@ -847,6 +892,10 @@ Examples:
   cfddns v6           Update the domain's A record to wan IPv6 IP.
   cfddns v6 2001:db8::1 Update the domain's AAAA record to 2001:db8::1.
   cfddns v46          Update the domain's A record to wan IPv4 and IPv6 IP.
+  cfddns once         Sync all records once; the exit code tells what happened.
+  cfddns check        Check conf.toml for problems and verify the Cloudflare API token.
+  cfddns --config /etc/cfddns.toml --interval 300
+                      Run with another config file and check every 300 seconds.
   cfddns v            Show the program version.
   cfddns ver          Show the program version.
   cfddns version      Show the program version.
@@ -858,10 +907,31 @@ Todo:
 
 Notes:
   - For commands like 'v4' and 'v6', the IP address must be valid, or an error will be shown.
-  - Ensure the configuration file is properly set up before running the program.
+  - Ensure the configuration file is properly set up before running the program; the daemon refuses to start on an invalid config.
   - system service Requires administrative privileges.
   - Services are registered differently on Windows and Linux.
   - Remove system service operation prompts if the service does not appear to be created by this program.
+  - The daemon stops gracefully on SIGINT/SIGTERM; send SIGUSR1 to trigger an immediate check (Linux/macOS).
+  - The daemon reloads conf.toml when the file changes or on SIGHUP (Linux/macOS); an invalid config is ignored.
+  - Every config key can also be set with an environment variable named CFDDNS_ plus the upper-case key,
+    e.g. CFDDNS_CF_API_TOKEN. Precedence: flags > environment variables > config file > defaults.
+  - Without a config file, the program runs on environment variables and flags alone.
+  - Cloudflare requests failing with HTTP 429, 5xx or network errors are retried with exponential backoff,
+    honoring Retry-After; authentication and validation errors are not retried.
+  - With listen = "127.0.0.1:8053" the daemon serves GET /healthz, GET /status (JSON), GET /metrics (Prometheus)
+    and POST /trigger (requires "Authorization: Bearer <api_token>") to run an immediate check.
+  - Exit codes of once, v4, v6 and v46: 0 nothing to update (or updated), 2 IP detection failed,
+    3 Cloudflare error, 4 config error; with exit_code_on_change = true, updates exit with 10.
+  - When a name has several A/AAAA records, 'duplicates' selects update_first (default), update_all, replace or fail.
+  - cf_zone_id / zone_id may be left empty; the zone is then looked up from the record name and cached.
+  - Secrets can be kept out of the config file with cf_api_token_file / tg_token_file,
+    or with env:NAME, file:/path and exec:command references, e.g. cf_api_token = "env:CF_API_TOKEN".
+  - [[notifiers]] sends one event to several channels; 'events' filters update_success, update_failure,
+    ip_failure, duplicates, startup and shutdown. notify = true with tg_* keeps working as a notifier named telegram.
+  - Notifier types: telegram, webhook (templated body, optional HMAC-SHA256 signature header),
+    email (SMTP with STARTTLS or implicit TLS, PLAIN/LOGIN auth, templated subject and body).
+    serverchan, pushplus, wecom, dingtalk and feishu (both with optional signing secret), bark,
+    slack (Block Kit), discord (embeds), matrix, gotify and ntfy (priority and tags).
 `
 	fmt.Println(helpMessage)
 }
@@ -870,57 +940,102 @@ func showVersion() {
 	fmt.Printf("CfDDNS - Cloudflare Dynamic DNS Updater\nVersion: %s\n", Version)
 }
 
-func (cf *CfDDNS) run() {
-	for {
-		cf.updateDNSRecord(cf.Config.CFIPType)
-		logMessage(fmt.Sprintf("Waiting %d seconds before the next check.", cf.Config.Interval))
-		time.Sleep(time.Duration(cf.Config.Interval) * time.Second)
+func main() {
+	// 解析命令行参数（排除程序本身的名称），--config 与配置项参数可以出现在命令前后
+	confPath, flags, args, err := parseArgs(os.Args[1:])
+	if err != nil {
+		slog.Error("Invalid arguments", "error", err)
+		fmt.Println("Usage: cfddns [options] [command] [arguments], see cfddns help")
+		os.Exit(exitConfigError)
 	}
-}
 
-func main() {
-	config := loadConfig()
-	cfddns := CfDDNS{Config: config}
-	// 检查是否带参数运行
-	args := os.Args[1:] // 获取命令行参数（排除程序本身的名称）
+	config, err := loadConfig(confPath, flags)
+	if err != nil {
+		slog.Error("Error loading config", "path", confPath, "error", err)
+		os.Exit(exitConfigError)
+	}
+	if err := setupLogging(config); err != nil {
+		slog.Error("Failed to set up log file", "error", err)
+	}
+	cfddns := newCfDDNS(config)
+	cfddns.configPath = confPath
+	cfddns.flagOverrides = flags
+	if config.DryRun {
+		slog.Warn("Dry run mode, DNS records will not be modified.")
+	}
+
+	// 收到 SIGINT/SIGTERM 时取消 ctx
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
+	// 检查是否带参数运行
 	if len(args) > 0 {
 		// 如果传递了参数
 		switch args[0] {
 		case "tgtest":
-			// 测试 Telegram 消息推送
-			testMessage := "This is a test message from CfDDNS."
-			logMessage("Executing Telegram test message...")
-			cfddns.tgMsg(testMessage)
-			logMessage("Test message sent successfully.")
+			// 测试 Telegram 消息推送，包括 notify = true 的旧版配置和 type = "telegram" 的通知渠道
+			slog.Info("Executing Telegram test message...")
+			if !cfddns.sendTest(ctx, func(n notifier) bool { return n.Type() == "telegram" }) {
+				os.Exit(exitError)
+			}
+		case "notify-test":
+			// 向指定名称的通知渠道发送测试消息，未指定名称时发送到所有渠道
+			if !cfddns.notifyTest(ctx, args[1:]) {
+				os.Exit(exitError)
+			}
 		case "ip":
-			cfddns.displayPublicIP()
+			cfddns.displayPublicIP(ctx)
 			displayCloudflareIPPriority()
-			// 
+			//
 		case "now":
 			// 查询并显示当前域名的 DNS 记录绑定的 IP
-			logMessage("Fetching current DNS record IPs...")
-			currentIPs := cfddns.getCurrentDNSRecordIP(cfddns.Config.CFIPType)
-			for ipType, ip := range currentIPs {
-				logMessage(fmt.Sprintf("Current DNS record IPv%s for %s: %s", ipType, cfddns.Config.CFRecordName, ip))
+			slog.Info("Fetching current DNS record IPs...")
+			cfddns.resolveZoneIDs(ctx)
+			for _, rec := range cfddns.Config.Records {
+				if rec.ZoneID == "" {
+					continue
+				}
+				if rec.zoneName != "" {
+					slog.Info("Zone ID looked up from record name", "record", rec.Name, "zone", rec.zoneName, "zone_id", rec.ZoneID)
+				}
+				currentIPs := cfddns.getCurrentDNSRecordIP(ctx, rec, rec.IPType)
+				for ipType, ip := range currentIPs {
+					recordLogger(rec, ipType).Info("Current DNS record IP", "ip", ip)
+				}
 			}
+		case "once":
+			// 对所有记录执行一次同步后退出，退出码表示同步结果
+			cfddns.oneShot = true
+			os.Exit(cfddns.runOnce(ctx))
 		case "v4", "v6", "v46":
+			if err := cfddns.Config.Validate(); err != nil {
+				logConfigErrors("Invalid config", confPath, err)
+				os.Exit(exitConfigError)
+			}
+			cfddns.oneShot = true
+			var result cycleResult
 			if len(args) < 2 {
 				ipType := args[0][1:] // 删除 "v" 前缀
-				logMessage(fmt.Sprintf("Executing updateDNSRecord with IP type: %s", ipType))
-				cfddns.updateDNSRecord(ipType)
-				os.Exit(1)
-			}
-			ip := args[1]
-			if args[0] == "v4" && isValidIPv4(ip) {
-				logMessage(fmt.Sprintf("Updating IPv4 record for %s to %s...", cfddns.Config.CFRecordName, ip))
-				cfddns.updateDNSRecordWithIP("4", ip)
-			} else if args[0] == "v6" && isValidIPv6(ip) {
-				logMessage(fmt.Sprintf("Updating IPv6 record for %s to %s...", cfddns.Config.CFRecordName, ip))
-				cfddns.updateDNSRecordWithIP("6", ip)
+				slog.Info("Executing updateDNSRecord", "ip_type", ipType)
+				result = cfddns.updateDNSRecord(ctx, ipType)
 			} else {
-				logMessage(fmt.Sprintf("Invalid IP address for %s: %s.", args[0], ip))
-				os.Exit(1)
+				ip := args[1]
+				if args[0] == "v4" && isValidIPv4(ip) {
+					slog.Info("Updating IPv4 records...", "new_ip", ip)
+					result = cfddns.updateDNSRecordWithIP(ctx, "4", ip)
+				} else if args[0] == "v6" && isValidIPv6(ip) {
+					slog.Info("Updating IPv6 records...", "new_ip", ip)
+					result = cfddns.updateDNSRecordWithIP(ctx, "6", ip)
+				} else {
+					slog.Error("Invalid IP address", "command", args[0], "ip", ip)
+					os.Exit(exitError)
+				}
+			}
+			os.Exit(result.exitCode(cfddns.Config.ExitCodeOnChange))
+		case "check":
+			// 校验配置并验证 Cloudflare API Token
+			if !cfddns.checkConfig(ctx) {
+				os.Exit(exitError)
 			}
 		case "h", "help":
 			// 显示帮助信息
@@ -934,7 +1049,7 @@ func main() {
 			if len(args) > 1 {
 				serviceName = args[1]
 			}
-			logMessage(fmt.Sprintf("Configuring service: %s", serviceName))
+			slog.Info("Configuring service", "service", serviceName)
 			setupService(serviceName)
 		case "rs", "removeservice":
 			// 移除服务
@@ -942,14 +1057,20 @@ func main() {
 			if len(args) > 1 {
 				serviceName = args[1]
 			}
-			logMessage(fmt.Sprintf("Removing service: %s", serviceName))
+			slog.Info("Removing service", "service", serviceName)
 			removeService(serviceName)
 		default:
-			logMessage(fmt.Sprintf("Unknown parameter: %s", args[0]))
-			logMessage("Usage: cfddns [tgtest]")
+			slog.Error("Unknown parameter", "parameter", args[0])
+			fmt.Println("Usage: cfddns [options] [command] [arguments], see cfddns help")
+			os.Exit(exitError)
 		}
 	} else {
 		// 未传递参数，执行原逻辑
-		cfddns.run()
+		// 配置无效时拒绝启动
+		if err := cfddns.Config.Validate(); err != nil {
+			logConfigErrors("Invalid config", confPath, err)
+			os.Exit(exitConfigError)
+		}
+		cfddns.run(ctx)
 	}
 }
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	"cfddns/internal/cloudflare"
+	"cfddns/internal/notify"
+)
+
+// aRecord 返回 a.example.com 的 A 记录
+func aRecord(id, ip string) cloudflare.DNSRecord {
+	return cloudflare.DNSRecord{ID: id, ZoneID: fakeZoneID, Type: "A", Name: "a.example.com", Content: ip, TTL: 1}
+}
+
+func TestSyncRecordUsesCache(t *testing.T) {
+	f := newFakeCloudflare(t, aRecord("r1", "192.0.2.1"))
+	cf := newTestCfDDNS(t, f, Config{Records: []RecordConfig{{Name: "a.example.com", IPType: "4"}}})
+	rec := cf.Config.Records[0]
+	ctx := context.Background()
+
+	if outcome, err := cf.syncRecord(ctx, rec, "4", "192.0.2.1"); err != nil || outcome != syncUnchanged {
+		t.Fatalf("first sync = %v, %v", outcome, err)
+	}
+	f.resetCounts()
+
+	// IP 未变化时不访问 Cloudflare
+	if outcome, err := cf.syncRecord(ctx, rec, "4", "192.0.2.1"); err != nil || outcome != syncUnchanged {
+		t.Fatalf("cached sync = %v, %v", outcome, err)
+	}
+	if got := f.count("GET") + f.count("PATCH"); got != 0 {
+		t.Errorf("cached sync made %d requests, want 0", got)
+	}
+
+	// IP 变化时直接使用缓存的记录 ID 更新，不再查询
+	if outcome, err := cf.syncRecord(ctx, rec, "4", "192.0.2.2"); err != nil || outcome != syncUpdated {
+		t.Fatalf("update = %v, %v", outcome, err)
+	}
+	if f.count("GET") != 0 || f.count("PATCH") != 1 {
+		t.Errorf("update made %d GET and %d PATCH requests, want 0 and 1", f.count("GET"), f.count("PATCH"))
+	}
+	if r, _ := f.record("r1"); r.Content != "192.0.2.2" {
+		t.Errorf("content = %s, want 192.0.2.2", r.Content)
+	}
+}
+
+func TestSyncRecordSettingsChangeSkipsCache(t *testing.T) {
+	f := newFakeCloudflare(t, aRecord("r1", "192.0.2.1"))
+	config := Config{Records: []RecordConfig{{Name: "a.example.com", IPType: "4"}}}
+	cf := newTestCfDDNS(t, f, config)
+	ctx := context.Background()
+
+	if _, err := cf.syncRecord(ctx, cf.Config.Records[0], "4", "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 重新加载后修改了 ttl，缓存未过期也应立即同步
+	ttl := 300
+	config = cf.Config
+	config.Records = []RecordConfig{config.Records[0]}
+	config.Records[0].TTL = &ttl
+	cf.applyConfig(config)
+	cf.useFake(f)
+
+	outcome, err := cf.syncRecord(ctx, cf.Config.Records[0], "4", "192.0.2.1")
+	if err != nil || outcome != syncUpdated {
+		t.Fatalf("sync after reload = %v, %v, want updated", outcome, err)
+	}
+	if r, _ := f.record("r1"); r.TTL != 300 {
+		t.Errorf("ttl = %d, want 300", r.TTL)
+	}
+
+	// 新设置同步后重新使用缓存
+	f.resetCounts()
+	if outcome, _ := cf.syncRecord(ctx, cf.Config.Records[0], "4", "192.0.2.1"); outcome != syncUnchanged || f.count("GET") != 0 {
+		t.Errorf("sync after settings applied = %v with %d GET requests, want unchanged from cache", outcome, f.count("GET"))
+	}
+}
+
+func TestSyncRecordResyncsDeletedCachedRecord(t *testing.T) {
+	f := newFakeCloudflare(t, aRecord("r1", "192.0.2.1"))
+	cf := newTestCfDDNS(t, f, Config{AddRecordIfMissing: true, Records: []RecordConfig{{Name: "a.example.com", IPType: "4"}}})
+	events := recordEvents(cf)
+	rec := cf.Config.Records[0]
+	ctx := context.Background()
+
+	if _, err := cf.syncRecord(ctx, rec, "4", "192.0.2.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	// 缓存的记录在 Cloudflare 上被手动删除，IP 变化时应重新查询并新建记录
+	f.remove("r1")
+	buf := captureDebugLog(t)
+	outcome, err := cf.syncRecord(ctx, rec, "4", "192.0.2.2")
+	if err != nil || outcome != syncUpdated {
+		t.Fatalf("sync = %v, %v, want updated", outcome, err)
+	}
+	if r, ok := f.record("r2"); !ok || r.Content != "192.0.2.2" {
+		t.Errorf("recreated record = %+v, %v", r, ok)
+	}
+	if cached, _ := cf.state.get(stateKey(rec, "4")); cached.RecordID != "r2" {
+		t.Errorf("cached record ID = %s, want r2", cached.RecordID)
+	}
+
+	out := buf.String()
+	if strings.Contains(out, "level=ERROR") {
+		t.Errorf("self-healing logged an error:\n%s", out)
+	}
+	if !strings.Contains(out, "Cached DNS record no longer exists") {
+		t.Errorf("resync not logged:\n%s", out)
+	}
+	if got := events.types(); !slices.Equal(got, []notify.EventType{notify.EventUpdateSuccess}) {
+		t.Errorf("events = %v, want [update_success]", got)
+	}
+}
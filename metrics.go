@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// counterVec 带标签的计数器，按 Prometheus 文本格式输出
+type counterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64 // 标签值以 \xff 连接作为键
+}
+
+func newCounterVec(name, help string, labels ...string) *counterVec {
+	return &counterVec{name: name, help: help, labels: labels, values: make(map[string]float64)}
+}
+
+// inc 计数加一，values 与 labels 一一对应
+func (c *counterVec) inc(values ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[strings.Join(values, "\xff")]++
+}
+
+func (c *counterVec) write(w io.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+	keys := make([]string, 0, len(c.values))
+	for key := range c.values {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, strings.Split(key, "\xff")), formatValue(c.values[key]))
+	}
+}
+
+// formatLabels 格式化标签，如 {method="GET",status="200"}
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabelValue(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatValue(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+// metricsRegistry cfddns 的全部计数器
+type metricsRegistry struct {
+	ipLookups     *counterVec
+	cloudflareAPI *counterVec
+	dnsUpdates    *counterVec
+	notifications *counterVec
+}
+
+// metrics 全局计数器，在 /metrics 中输出
+var metrics = &metricsRegistry{
+	ipLookups: newCounterVec("cfddns_ip_lookups_total",
+		"IP lookups by source, IP family and outcome.", "source", "ip_type", "outcome"),
+	cloudflareAPI: newCounterVec("cfddns_cloudflare_requests_total",
+		"Cloudflare API requests by method and HTTP status, status is \"error\" when no response was received.", "method", "status"),
+	dnsUpdates: newCounterVec("cfddns_dns_updates_total",
+		"DNS record changes by action (create, update, delete) and result.", "type", "action", "result"),
+	notifications: newCounterVec("cfddns_notifications_total",
+		"Notifications sent by notifier and result.", "notifier", "type", "result"),
+}
+
+// resultLabel 根据 err 返回 success 或 failure
+func resultLabel(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// cloudflareRequest 作为 cloudflare.Client.OnResponse 记录每次 API 请求
+func (m *metricsRegistry) cloudflareRequest(method string, statusCode int) {
+	status := "error"
+	if statusCode > 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	m.cloudflareAPI.inc(method, status)
+}
+
+// writeMetrics 按 Prometheus 文本格式输出计数器和每条记录的时间戳
+func writeMetrics(w io.Writer, statuses []recordStatus) {
+	fmt.Fprintf(w, "# HELP cfddns_build_info Build information.\n# TYPE cfddns_build_info gauge\n")
+	fmt.Fprintf(w, "cfddns_build_info%s 1\n", formatLabels([]string{"version"}, []string{Version}))
+
+	metrics.ipLookups.write(w)
+	metrics.cloudflareAPI.write(w)
+	metrics.dnsUpdates.write(w)
+	metrics.notifications.write(w)
+
+	labels := []string{"zone", "name", "type"}
+	fmt.Fprintf(w, "# HELP cfddns_record_last_success_timestamp_seconds Unix time of the last successful check of the record.\n")
+	fmt.Fprintf(w, "# TYPE cfddns_record_last_success_timestamp_seconds gauge\n")
+	for _, st := range statuses {
+		if st.LastSuccess != nil {
+			fmt.Fprintf(w, "cfddns_record_last_success_timestamp_seconds%s %d\n",
+				formatLabels(labels, []string{st.Zone, st.Name, st.Type}), st.LastSuccess.Unix())
+		}
+	}
+	fmt.Fprintf(w, "# HELP cfddns_record_last_change_timestamp_seconds Unix time of the last change of the record.\n")
+	fmt.Fprintf(w, "# TYPE cfddns_record_last_change_timestamp_seconds gauge\n")
+	for _, st := range statuses {
+		if st.LastChange != nil {
+			fmt.Fprintf(w, "cfddns_record_last_change_timestamp_seconds%s %d\n",
+				formatLabels(labels, []string{st.Zone, st.Name, st.Type}), st.LastChange.Unix())
+		}
+	}
+}
+
+// handleMetrics Prometheus 抓取接口
+func (a *httpAPI) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writeMetrics(w, a.status.snapshot())
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+	"time"
+
+	"cfddns/internal/notify"
+)
+
+// notifyTimeout 单个通知渠道发送一条通知的超时时间
+const notifyTimeout = 15 * time.Second
+
+// legacyNotifierName 旧版本 notify/tg_* 配置对应的通知渠道名称
+const legacyNotifierName = "telegram"
+
+// notifier 已创建的通知渠道及其配置
+type notifier struct {
+	notify.Notifier
+	config notify.Config
+}
+
+// notifierConfigs 返回所有通知渠道的配置，notify = true 时把 tg_* 配置转换为名为 telegram 的渠道
+func (c Config) notifierConfigs() []notify.Config {
+	var configs []notify.Config
+	if c.Notify {
+		events := []string{
+			string(notify.EventUpdateSuccess),
+			string(notify.EventUpdateFailure),
+			string(notify.EventIPFailure),
+			string(notify.EventDuplicates),
+		}
+		if c.NotifyShutdown {
+			events = append(events, string(notify.EventShutdown))
+		}
+		configs = append(configs, notify.Config{
+			Name:   legacyNotifierName,
+			Type:   "telegram",
+			Events: events,
+			Telegram: &notify.TelegramConfig{
+				APIURL: c.TgApiUrl,
+				Token:  c.TGToken,
+				ChatID: c.TGChatID,
+			},
+		})
+	}
+	return append(configs, c.Notifiers...)
+}
+
+// buildNotifiers 根据配置创建通知渠道，无法创建的渠道记录错误后跳过
+func buildNotifiers(config Config) []notifier {
+	var notifiers []notifier
+	for _, nc := range config.notifierConfigs() {
+		n, err := notify.New(nc, notifyHTTPClient)
+		if err != nil {
+			slog.Error("Failed to set up notifier", "notifier", nc.Name, "error", err)
+			continue
+		}
+		notifiers = append(notifiers, notifier{Notifier: n, config: nc})
+	}
+	return notifiers
+}
+
+// notify 把事件依次发送到所有订阅了该事件的通知渠道，发送失败只记录日志
+func (cf *CfDDNS) notify(ctx context.Context, event notify.Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.Hostname == "" {
+		event.Hostname, _ = os.Hostname()
+	}
+
+	// 演练模式默认不发送通知，notify_dry_run 开启时发送并标注，测试消息除外
+	if cf.Config.DryRun && event.Type != notify.EventTest {
+		if !cf.Config.NotifyDryRun {
+			slog.Info("Dry run, notification suppressed.", "event", event.Type, "message", event.Message)
+			return
+		}
+		event.DryRun = true
+		event.Message = dryRunPrefix + " " + event.Message
+	}
+
+	for _, n := range cf.notifiers {
+		if !n.config.Wants(event.Type) {
+			continue
+		}
+		sendCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		err := n.Send(sendCtx, event)
+		cancel()
+		metrics.notifications.inc(n.Name(), n.Type(), resultLabel(err))
+		if err != nil {
+			slog.Error("Failed to send notification", "notifier", n.Name(), "type", n.Type(), "event", event.Type, "error", err)
+			continue
+		}
+		slog.Info("Notification sent successfully.", "notifier", n.Name(), "type", n.Type(), "event", event.Type)
+	}
+}
+
+// notifyTest 向 names 中的通知渠道发送测试消息，names 为空时发送到所有渠道
+func (cf *CfDDNS) notifyTest(ctx context.Context, names []string) bool {
+	var configured []string
+	for _, n := range cf.notifiers {
+		configured = append(configured, n.Name())
+	}
+	for _, name := range names {
+		if !slices.Contains(configured, name) {
+			slog.Error("Notifier not found", "name", name, "configured", configured)
+			return false
+		}
+	}
+	return cf.sendTest(ctx, func(n notifier) bool {
+		return len(names) == 0 || slices.Contains(names, n.Name())
+	})
+}
+
+// sendTest 向 match 返回 true 的通知渠道发送测试消息，不受 events 过滤
+// 没有匹配的渠道或有渠道发送失败时返回 false
+func (cf *CfDDNS) sendTest(ctx context.Context, match func(notifier) bool) bool {
+	hostname, _ := os.Hostname()
+	event := notify.Event{
+		Type:     notify.EventTest,
+		Time:     time.Now(),
+		Hostname: hostname,
+		Message:  "This is a test message from CfDDNS on " + hostname + ".",
+		Success:  true,
+	}
+
+	ok, sent := true, 0
+	for _, n := range cf.notifiers {
+		if !match(n) {
+			continue
+		}
+		sent++
+		sendCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		err := n.Send(sendCtx, event)
+		cancel()
+		metrics.notifications.inc(n.Name(), n.Type(), resultLabel(err))
+		if err != nil {
+			slog.Error("Failed to send test message", "notifier", n.Name(), "type", n.Type(), "error", err)
+			ok = false
+			continue
+		}
+		slog.Info("Test message sent successfully.", "notifier", n.Name(), "type", n.Type())
+	}
+	if sent == 0 {
+		slog.Error("No matching notifier configured.")
+		return false
+	}
+	return ok
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// errZoneNotFound 记录未配置 zone_id 且自动查找失败
+var errZoneNotFound = errors.New("zone not found, set zone_id")
+
+// 进程退出码
+const (
+	exitOK              = 0
+	exitError           = 1  // 其他错误，如参数不合法
+	exitIPFailed        = 2  // 获取公网 IP 失败
+	exitCloudflareError = 3  // Cloudflare 请求失败或记录无法处理
+	exitConfigError     = 4  // 配置文件或参数无效
+	exitChanged         = 10 // 有记录被更新，仅在开启 exit_code_on_change 时使用
+)
+
+// syncOutcome 单条记录一次同步的结果
+type syncOutcome int
+
+const (
+	syncUnchanged syncOutcome = iota // 记录已是最新，无需修改
+	syncUpdated                      // 记录被创建或更新
+	syncFailed                       // Cloudflare 请求失败或记录无法处理
+)
+
+// cycleResult 一次同步周期的汇总结果
+type cycleResult struct {
+	Updated   int
+	Unchanged int
+	Failed    int
+	IPFailed  bool // 至少一个协议族获取公网 IP 失败
+}
+
+// add 累计单条记录的同步结果
+func (r *cycleResult) add(outcome syncOutcome) {
+	switch outcome {
+	case syncUpdated:
+		r.Updated++
+	case syncFailed:
+		r.Failed++
+	default:
+		r.Unchanged++
+	}
+}
+
+// exitCode 返回周期结果对应的退出码，获取 IP 失败优先于 Cloudflare 错误
+func (r cycleResult) exitCode(codeOnChange bool) int {
+	switch {
+	case r.IPFailed:
+		return exitIPFailed
+	case r.Failed > 0:
+		return exitCloudflareError
+	case r.Updated > 0 && codeOnChange:
+		return exitChanged
+	default:
+		return exitOK
+	}
+}
+
+// runOnce 校验配置后对所有记录执行一次同步，返回进程退出码，适用于 cron 等定时任务
+func (cf *CfDDNS) runOnce(ctx context.Context) int {
+	if err := cf.Config.Validate(); err != nil {
+		logConfigErrors("Invalid config", cf.configPath, err)
+		return exitConfigError
+	}
+
+	result := cf.updateDNSRecord(ctx, "")
+	slog.Info("Sync finished.", "updated", result.Updated, "unchanged", result.Unchanged,
+		"failed", result.Failed, "ip_failed", result.IPFailed)
+	return result.exitCode(cf.Config.ExitCodeOnChange)
+}
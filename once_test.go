@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"cfddns/internal/cloudflare"
+)
+
+func TestCycleResultExitCode(t *testing.T) {
+	tests := []struct {
+		result       cycleResult
+		codeOnChange bool
+		want         int
+	}{
+		{cycleResult{}, false, exitOK},
+		{cycleResult{Unchanged: 2}, true, exitOK},
+		{cycleResult{Updated: 1}, false, exitOK},
+		{cycleResult{Updated: 1}, true, exitChanged},
+		{cycleResult{Updated: 1, Failed: 1}, true, exitCloudflareError},
+		{cycleResult{Failed: 1, IPFailed: true}, false, exitIPFailed},
+		{cycleResult{Updated: 1, IPFailed: true}, true, exitIPFailed},
+	}
+	for _, tt := range tests {
+		if got := tt.result.exitCode(tt.codeOnChange); got != tt.want {
+			t.Errorf("%+v.exitCode(%v) = %d, want %d", tt.result, tt.codeOnChange, got, tt.want)
+		}
+	}
+}
+
+func TestRunOnceExitCodes(t *testing.T) {
+	ipSource := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "192.0.2.2")
+	}))
+	defer ipSource.Close()
+
+	tests := []struct {
+		name    string
+		records []cloudflare.DNSRecord
+		extra   map[string]string // 覆盖默认配置的配置项，值为 TOML
+		want    int
+	}{
+		{"updated", []cloudflare.DNSRecord{aRecord("r1", "192.0.2.1")}, nil, exitOK},
+		{"updated with exit_code_on_change", []cloudflare.DNSRecord{aRecord("r1", "192.0.2.1")}, map[string]string{"exit_code_on_change": "true"}, exitChanged},
+		{"unchanged with exit_code_on_change", []cloudflare.DNSRecord{aRecord("r1", "192.0.2.2")}, map[string]string{"exit_code_on_change": "true"}, exitOK},
+		{"record missing", nil, nil, exitCloudflareError},
+		{"duplicates fail", []cloudflare.DNSRecord{aRecord("r1", "192.0.2.1"), aRecord("r2", "192.0.2.9")}, map[string]string{"duplicates": `"fail"`}, exitCloudflareError},
+		{"ip detection failed", []cloudflare.DNSRecord{aRecord("r1", "192.0.2.1")}, map[string]string{"get_ipv4_url": `"` + ipSource.URL + `/fail"`}, exitIPFailed},
+		{"invalid config", nil, map[string]string{"cf_record_name": `""`}, exitConfigError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeCloudflare(t, tt.records...)
+			dir := t.TempDir()
+			values := map[string]string{
+				"cf_api_token":          `"token"`,
+				"cf_zone_id":            `"` + fakeZoneID + `"`,
+				"cf_record_name":        `"a.example.com"`,
+				"cf_ip_type":            `"4"`,
+				"get_ipv4_url":          `"` + ipSource.URL + `"`,
+				"retry_count":           "1",
+				"add_record_if_missing": "false",
+				"state_dir":             strconv.Quote(dir),
+			}
+			maps.Copy(values, tt.extra)
+			var conf strings.Builder
+			for key, value := range values {
+				fmt.Fprintf(&conf, "%s = %s\n", key, value)
+			}
+			confPath := filepath.Join(dir, "conf.toml")
+			if err := os.WriteFile(confPath, []byte(conf.String()), 0600); err != nil {
+				t.Fatal(err)
+			}
+			config, err := readConfig(confPath, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			cf := newCfDDNS(config)
+			cf.configPath = confPath
+			cf.useFake(f)
+			if got := cf.runOnce(context.Background()); got != tt.want {
+				t.Errorf("runOnce() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
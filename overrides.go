@@ -0,0 +1,171 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// envPrefix 环境变量前缀，配置项 cf_api_token 对应 CFDDNS_CF_API_TOKEN
+const envPrefix = "CFDDNS_"
+
+// configField Config 中可以被覆盖的配置项
+type configField struct {
+	key   string // toml 键名
+	index int    // 字段在 Config 中的序号
+	kind  reflect.Kind
+}
+
+// configFields 返回 Config 中所有带 toml 标签的字段
+func configFields() []configField {
+	t := reflect.TypeOf(Config{})
+	fields := make([]configField, 0, t.NumField())
+	for i := range t.NumField() {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if !f.IsExported() || key == "" || key == "-" {
+			continue
+		}
+		fields = append(fields, configField{key: key, index: i, kind: f.Type.Kind()})
+	}
+	return fields
+}
+
+// envName 返回配置项对应的环境变量名
+func envName(key string) string {
+	return envPrefix + strings.ToUpper(key)
+}
+
+// flagName 返回配置项对应的命令行参数名，cf_api_token 对应 --cf-api-token
+func flagName(key string) string {
+	return strings.ReplaceAll(key, "_", "-")
+}
+
+// setConfigValue 设置单个配置项
+// 字符串直接赋值，其他类型按 TOML 值解析，如 true、300、["a", "b"]、[{ name = "a" }]
+func setConfigValue(config *Config, field configField, value string) error {
+	if field.kind == reflect.String {
+		reflect.ValueOf(config).Elem().Field(field.index).SetString(value)
+		return nil
+	}
+	return toml.Unmarshal([]byte(field.key+" = "+value), config)
+}
+
+// hasEnvOverrides 判断是否设置了 CFDDNS_ 开头的配置环境变量
+func hasEnvOverrides() bool {
+	for _, field := range configFields() {
+		if _, ok := os.LookupEnv(envName(field.key)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// 配置项来源的优先级，数值大的优先
+const (
+	levelFile = iota
+	levelEnv
+	levelFlag
+)
+
+// applyOverrides 依次使用环境变量和命令行参数覆盖配置，
+// 并在 config.sources 中记录被覆盖的配置项来源
+func applyOverrides(config *Config, flags map[string]string) error {
+	fields := configFields()
+	levels := make(map[string]int)
+	for _, field := range fields {
+		if value, ok := os.LookupEnv(envName(field.key)); ok {
+			if err := setConfigValue(config, field, value); err != nil {
+				return fmt.Errorf("invalid value for environment variable %s: %w", envName(field.key), err)
+			}
+			config.setSource(field.key, "env "+envName(field.key))
+			levels[field.key] = levelEnv
+		}
+		if value, ok := flags[field.key]; ok {
+			if err := setConfigValue(config, field, value); err != nil {
+				return fmt.Errorf("invalid value for flag --%s: %w", flagName(field.key), err)
+			}
+			config.setSource(field.key, "flag --"+flagName(field.key))
+			levels[field.key] = levelFlag
+		}
+	}
+
+	// <key>_file 在解析密钥时优先于 <key>，<key> 来自更高优先级的来源时清除 <key>_file，
+	// 如 --cf-api-token 覆盖配置文件中的 cf_api_token_file
+	for _, field := range fields {
+		if !strings.HasSuffix(field.key, "_file") || field.kind != reflect.String {
+			continue
+		}
+		key := strings.TrimSuffix(field.key, "_file")
+		if level, ok := levels[key]; ok && level > levels[field.key] {
+			reflect.ValueOf(config).Elem().Field(field.index).SetString("")
+		}
+	}
+	return nil
+}
+
+// setSource 记录配置项的来源
+func (c *Config) setSource(key, source string) {
+	if c.sources == nil {
+		c.sources = make(map[string]string)
+	}
+	c.sources[key] = source
+}
+
+// boolFlag 布尔类型的配置项，支持 --debug 和 --debug=false 两种写法
+type boolFlag func(string) error
+
+func (f boolFlag) String() string { return "" }
+
+func (f boolFlag) Set(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("invalid boolean value %q", value)
+	}
+	return f(value)
+}
+
+func (f boolFlag) IsBoolFlag() bool { return true }
+
+// parseArgs 解析命令行参数，参数可以出现在命令前后
+// 返回配置文件路径、通过参数覆盖的配置项（toml 键 → 值）以及剩余的命令和参数
+func parseArgs(args []string) (string, map[string]string, []string, error) {
+	confPath := defaultConfigPath
+	flags := make(map[string]string)
+
+	fs := flag.NewFlagSet("cfddns", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	fs.StringVar(&confPath, "config", defaultConfigPath, "path to the config file")
+	for _, field := range configFields() {
+		key := field.key
+		set := func(value string) error {
+			flags[key] = value
+			return nil
+		}
+		usage := fmt.Sprintf("override %s (env %s)", key, envName(key))
+		if field.kind == reflect.Bool {
+			fs.Var(boolFlag(set), flagName(key), usage)
+		} else {
+			fs.Func(flagName(key), usage, set)
+		}
+	}
+
+	var rest []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return "", nil, nil, err
+		}
+		if fs.NArg() == 0 {
+			break
+		}
+		rest = append(rest, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	return confPath, flags, rest, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSecretFileOverridePrecedence(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	fileToken := writeFile("file_token", "from-config-file\n")
+	envFileToken := writeFile("env_file_token", "from-env-file\n")
+	flagFileToken := writeFile("flag_file_token", "from-flag-file\n")
+	confPath := writeFile("conf.toml", "cf_api_token = \"from-config\"\ncf_api_token_file = \""+filepath.ToSlash(fileToken)+"\"\n")
+
+	tests := []struct {
+		name  string
+		env   map[string]string
+		flags map[string]string
+		want  string
+	}{
+		{"config file only", nil, nil, "from-config-file"},
+		{"env token overrides config token_file", map[string]string{"CFDDNS_CF_API_TOKEN": "from-env"}, nil, "from-env"},
+		{"flag token overrides config token_file", nil, map[string]string{"cf_api_token": "from-flag"}, "from-flag"},
+		{"flag token overrides env token_file",
+			map[string]string{"CFDDNS_CF_API_TOKEN_FILE": envFileToken},
+			map[string]string{"cf_api_token": "from-flag"}, "from-flag"},
+		{"token_file wins at the same level",
+			map[string]string{"CFDDNS_CF_API_TOKEN": "from-env", "CFDDNS_CF_API_TOKEN_FILE": envFileToken}, nil, "from-env-file"},
+		{"flag token_file overrides env token",
+			map[string]string{"CFDDNS_CF_API_TOKEN": "from-env"},
+			map[string]string{"cf_api_token_file": flagFileToken}, "from-flag-file"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			config, err := readConfig(confPath, tt.flags)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.CFApiToken != tt.want {
+				t.Errorf("cf_api_token = %q, want %q", config.CFApiToken, tt.want)
+			}
+		})
+	}
+}
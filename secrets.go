@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// secretExecTimeout exec: 引用执行命令的超时时间
+const secretExecTimeout = 10 * time.Second
+
+// secretField 带 secret:"true" 标签的配置项
+type secretField struct {
+	key   string        // 配置项路径，如 cf_api_token
+	value reflect.Value // 字段值，可写
+	file  reflect.Value // 对应的 <key>_file 字段，不存在时无效
+}
+
+// secretFields 递归查找 v 中所有带 secret:"true" 标签的字符串字段
+// v 为结构体，prefix 为其在配置中的路径
+func secretFields(v reflect.Value, prefix string) []secretField {
+	var fields []secretField
+	t := v.Type()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		key, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+		if !f.IsExported() || key == "" || key == "-" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		fv := v.Field(i)
+
+		if f.Tag.Get("secret") == "true" && f.Type.Kind() == reflect.String {
+			field := secretField{key: key, value: fv}
+			if ff, ok := t.FieldByName(f.Name + "File"); ok && ff.Type.Kind() == reflect.String {
+				field.file = v.FieldByIndex(ff.Index)
+			}
+			fields = append(fields, field)
+			continue
+		}
+
+		switch fv.Kind() {
+		case reflect.Struct:
+			fields = append(fields, secretFields(fv, key)...)
+		case reflect.Pointer:
+			if !fv.IsNil() && fv.Elem().Kind() == reflect.Struct {
+				fields = append(fields, secretFields(fv.Elem(), key)...)
+			}
+		case reflect.Slice:
+			for j := range fv.Len() {
+				if elem := fv.Index(j); elem.Kind() == reflect.Struct {
+					fields = append(fields, secretFields(elem, fmt.Sprintf("%s[%d]", key, j))...)
+				}
+			}
+		}
+	}
+	return fields
+}
+
+// resolveSecrets 解析配置中的密钥
+// <key>_file 优先于 <key>，值为 env:NAME、file:/path、exec:command args 时从对应位置读取
+func resolveSecrets(config *Config) error {
+	for _, field := range secretFields(reflect.ValueOf(config).Elem(), "") {
+		if field.file.IsValid() && field.file.String() != "" {
+			value, err := readSecretFile(field.file.String())
+			if err != nil {
+				return fmt.Errorf("%s_file: %w", field.key, err)
+			}
+			field.value.SetString(value)
+			continue
+		}
+
+		value, err := resolveSecret(field.value.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.key, err)
+		}
+		field.value.SetString(value)
+	}
+	return nil
+}
+
+// resolveSecret 解析单个密钥引用，不是引用时原样返回
+func resolveSecret(ref string) (string, error) {
+	scheme, rest, _ := strings.Cut(ref, ":")
+	switch scheme {
+	case "env":
+		value, ok := os.LookupEnv(rest)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", rest)
+		}
+		return strings.TrimSpace(value), nil
+	case "file":
+		return readSecretFile(rest)
+	case "exec":
+		args := strings.Fields(rest)
+		if len(args) == 0 {
+			return "", errors.New("exec: empty command")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), secretExecTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, args[0], args[1:]...).Output()
+		if err != nil {
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+				return "", fmt.Errorf("exec %s: %w: %s", args[0], err, strings.TrimSpace(string(exitErr.Stderr)))
+			}
+			return "", fmt.Errorf("exec %s: %w", args[0], err)
+		}
+		return strings.TrimSpace(string(out)), nil
+	default:
+		return ref, nil
+	}
+}
+
+// readSecretFile 读取密钥文件，去掉首尾空白和换行
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// isSecretRef 判断密钥是否为 env:/file:/exec: 引用
+func isSecretRef(value string) bool {
+	scheme, _, ok := strings.Cut(value, ":")
+	return ok && (scheme == "env" || scheme == "file" || scheme == "exec")
+}
+
+// hasPlaintextSecrets 判断配置文件中是否直接写有密钥（占位符和引用除外）
+func hasPlaintextSecrets(config *Config) bool {
+	for _, field := range secretFields(reflect.ValueOf(config).Elem(), "") {
+		value := field.value.String()
+		if value != "" && !isSecretRef(value) && !placeholderPattern.MatchString(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// warnConfigPermissions 配置文件中写有密钥且同组或其他用户可读时给出警告
+func warnConfigPermissions(confPath string, config Config) {
+	// Windows 上的文件权限位没有意义
+	if runtime.GOOS == "windows" || !config.plaintextSecrets {
+		return
+	}
+	info, err := os.Stat(confPath)
+	if err != nil {
+		return
+	}
+	if perm := info.Mode().Perm(); perm&0o077 != 0 {
+		slog.Warn("Config file contains secrets and is readable by other users, consider chmod 600 or using *_file / env: / file: secrets",
+			"path", confPath, "mode", fmt.Sprintf("%04o", perm))
+	}
+}
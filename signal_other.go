@@ -0,0 +1,11 @@
+//go:build !unix
+
+package main
+
+import "os"
+
+// notifyTrigger 当前平台不支持 SIGUSR1
+func notifyTrigger(c chan<- os.Signal) {}
+
+// notifyReload 当前平台不支持 SIGHUP，只能通过修改配置文件触发重新加载
+func notifyReload(c chan<- os.Signal) {}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"cfddns/internal/cloudflare"
+)
+
+// stateFileName 状态文件名，位于 state_dir 下
+const stateFileName = "cfddns.state.json"
+
+// recordState 单条记录（zone + 名称 + 类型）最后一次同步的结果
+type recordState struct {
+	RecordID  string    `json:"record_id"`
+	RecordIDs []string  `json:"record_ids,omitempty"` // update_all 同步了多条记录时的全部记录 ID
+	IP        string    `json:"ip"`                   // 最后一次推送或确认的 IP
+	Settings  string    `json:"settings,omitempty"`   // 同步时配置的 ttl、proxied、comment、tags 的摘要
+	SyncedAt  time.Time `json:"synced_at"`            // 最后一次与 Cloudflare 确认的时间
+}
+
+// newRecordState 返回记录刚刚与 Cloudflare 确认为 ip 时的缓存状态
+func newRecordState(rec RecordConfig, recordID, ip string) recordState {
+	return recordState{RecordID: recordID, IP: ip, Settings: recordSettings(rec), SyncedAt: time.Now()}
+}
+
+// syncedState 返回 targets 全部同步为 ip 后的缓存状态，多条记录（update_all）时保存所有记录的 ID
+func syncedState(rec RecordConfig, targets []cloudflare.DNSRecord, ip string) recordState {
+	st := newRecordState(rec, targets[0].ID, ip)
+	if len(targets) > 1 {
+		for _, target := range targets {
+			st.RecordIDs = append(st.RecordIDs, target.ID)
+		}
+	}
+	return st
+}
+
+// recordIDs 返回缓存的所有记录 ID
+func (st recordState) recordIDs() []string {
+	if len(st.RecordIDs) > 0 {
+		return st.RecordIDs
+	}
+	return []string{st.RecordID}
+}
+
+// recordSettings 返回记录中需要强制同步的设置的摘要，设置变化后缓存失效
+// 未设置任何一项时返回空字符串，与旧版本的状态文件兼容
+func recordSettings(rec RecordConfig) string {
+	if rec.TTL == nil && rec.Proxied == nil && rec.Comment == nil && rec.Tags == nil {
+		return ""
+	}
+	data, _ := json.Marshal([]any{rec.TTL, rec.Proxied, rec.Comment, rec.Tags})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// zoneState 自动查找到的记录所属 zone
+type zoneState struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// stateData 状态文件的内容
+type stateData struct {
+	Records map[string]recordState `json:"records"`
+	Zones   map[string]zoneState   `json:"zones,omitempty"` // 记录名称 → zone
+	// 记录键 → 已通知过的重复记录集合，duplicates 为 fail 或 update_first 时同一组重复记录只通知一次
+	Duplicates map[string]string `json:"duplicates,omitempty"`
+}
+
+// stateStore 本地状态缓存，用于避免每个周期都查询 Cloudflare
+type stateStore struct {
+	mu   sync.Mutex
+	path string
+	data stateData
+}
+
+// stateKey 返回记录在状态文件中的键
+func stateKey(rec RecordConfig, ipType string) string {
+	return fmt.Sprintf("%s/%s/%s", rec.ZoneID, rec.Name, recordTypeOf(ipType))
+}
+
+// loadState 读取 dir 下的状态文件，文件不存在或损坏时使用空状态
+func loadState(dir string) *stateStore {
+	s := &stateStore{
+		path: filepath.Join(dir, stateFileName),
+		data: stateData{Records: make(map[string]recordState), Zones: make(map[string]zoneState), Duplicates: make(map[string]string)},
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to read state file, starting with empty state", "path", s.path, "error", err)
+		}
+		return s
+	}
+	if err := json.Unmarshal(data, &s.data); err != nil {
+		slog.Warn("Failed to parse state file, starting with empty state", "path", s.path, "error", err)
+		s.data = stateData{}
+	}
+	if s.data.Records == nil {
+		s.data.Records = make(map[string]recordState)
+	}
+	if s.data.Zones == nil {
+		s.data.Zones = make(map[string]zoneState)
+	}
+	if s.data.Duplicates == nil {
+		s.data.Duplicates = make(map[string]string)
+	}
+	return s
+}
+
+// get 获取记录的缓存状态
+func (s *stateStore) get(key string) (recordState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.data.Records[key]
+	return st, ok
+}
+
+// set 更新记录的缓存状态并写入文件
+func (s *stateStore) set(key string, st recordState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Records[key] = st
+	s.save()
+}
+
+// forget 删除记录的缓存状态并写入文件
+func (s *stateStore) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Records[key]; !ok {
+		return
+	}
+	delete(s.data.Records, key)
+	s.save()
+}
+
+// getZone 获取记录名称对应的缓存 zone
+func (s *stateStore) getZone(name string) (zoneState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	zone, ok := s.data.Zones[name]
+	return zone, ok
+}
+
+// setZone 缓存记录名称对应的 zone 并写入文件
+func (s *stateStore) setZone(name string, zone zoneState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Zones[name] = zone
+	s.save()
+}
+
+// forgetZone 删除记录名称对应的缓存 zone 并写入文件
+func (s *stateStore) forgetZone(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.data.Zones[name]; !ok {
+		return
+	}
+	delete(s.data.Zones, name)
+	s.save()
+}
+
+// duplicatesReported 判断该组重复记录是否已经通知过
+func (s *stateStore) duplicatesReported(key, fingerprint string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.data.Duplicates[key] == fingerprint
+}
+
+// setDuplicates 记录已通知的重复记录并写入文件，fingerprint 为空时清除
+func (s *stateStore) setDuplicates(key, fingerprint string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.data.Duplicates[key] == fingerprint {
+		return
+	}
+	if fingerprint == "" {
+		delete(s.data.Duplicates, key)
+	} else {
+		s.data.Duplicates[key] = fingerprint
+	}
+	s.save()
+}
+
+// save 先写临时文件再重命名，避免写入中断导致状态文件损坏，调用方需持有锁
+func (s *stateStore) save() {
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		slog.Error("Failed to encode state", "error", err)
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		slog.Error("Failed to create state directory", "path", s.path, "error", err)
+		return
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		slog.Error("Failed to write state file", "path", tmp, "error", err)
+		return
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		slog.Error("Failed to replace state file", "path", s.path, "error", err)
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// recordStatus 单条记录（名称 + 类型）最近一次同步的结果
+type recordStatus struct {
+	Zone        string     `json:"zone,omitempty"`
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	DetectedIP  string     `json:"detected_ip,omitempty"` // 最近一次检测到的公网 IP
+	DNSIP       string     `json:"dns_ip,omitempty"`      // Cloudflare 上记录的 IP
+	LastCheck   *time.Time `json:"last_check,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"` // 最近一次成功检查或更新的时间
+	LastChange  *time.Time `json:"last_change,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+}
+
+// statusTracker 保存每条记录的同步状态，供 HTTP API 查询，可并发访问
+type statusTracker struct {
+	mu      sync.Mutex
+	order   []string // 按配置顺序输出
+	records map[string]*recordStatus
+}
+
+func newStatusTracker() *statusTracker {
+	return &statusTracker{records: make(map[string]*recordStatus)}
+}
+
+// statusKey 返回记录在 statusTracker 中的键，zone 可能在之后才自动查找到，因此不参与
+func statusKey(rec RecordConfig, ipType string) string {
+	return rec.Name + "/" + recordTypeOf(ipType)
+}
+
+// reset 按新的记录配置重建状态列表，保留仍然存在的记录的状态
+// 未同步过的记录使用状态文件中缓存的 IP 作为 DNS IP
+func (t *statusTracker) reset(records []RecordConfig, state *stateStore) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	order := make([]string, 0, len(records))
+	statuses := make(map[string]*recordStatus, len(records))
+	for _, rec := range records {
+		for _, ipType := range ipTypesOf(rec.IPType) {
+			key := statusKey(rec, ipType)
+			if _, ok := statuses[key]; ok {
+				continue
+			}
+			st, ok := t.records[key]
+			if !ok {
+				st = &recordStatus{Zone: rec.ZoneID, Name: rec.Name, Type: recordTypeOf(ipType)}
+				if cached, ok := state.get(stateKey(rec, ipType)); ok {
+					st.DNSIP = cached.IP
+				}
+			}
+			order = append(order, key)
+			statuses[key] = st
+		}
+	}
+	t.order, t.records = order, statuses
+}
+
+// update 修改记录的状态，记录不在列表中时（如通过 v4/v6 命令指定了其他协议族）追加
+func (t *statusTracker) update(rec RecordConfig, ipType string, fn func(st *recordStatus)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := statusKey(rec, ipType)
+	st, ok := t.records[key]
+	if !ok {
+		st = &recordStatus{Name: rec.Name, Type: recordTypeOf(ipType)}
+		t.order = append(t.order, key)
+		t.records[key] = st
+	}
+	st.Zone = rec.ZoneID
+	fn(st)
+}
+
+// checked 记录一次同步的结果
+func (t *statusTracker) checked(rec RecordConfig, ipType, ip string, outcome syncOutcome, err error, dryRun bool) {
+	now := time.Now()
+	t.update(rec, ipType, func(st *recordStatus) {
+		st.LastCheck = &now
+		if ip != "" {
+			st.DetectedIP = ip
+		}
+		if err != nil {
+			st.LastError = err.Error()
+			return
+		}
+		st.LastError = ""
+		st.LastSuccess = &now
+		// 演练模式下记录并未被修改
+		if outcome == syncUpdated && dryRun {
+			return
+		}
+		if outcome == syncUpdated {
+			st.LastChange = &now
+		}
+		st.DNSIP = ip
+	})
+}
+
+// snapshot 返回当前所有记录状态的副本
+func (t *statusTracker) snapshot() []recordStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	list := make([]recordStatus, 0, len(t.order))
+	for _, key := range t.order {
+		list = append(list, *t.records[key])
+	}
+	return list
+}
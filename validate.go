@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+)
+
+// ConfigError 单个配置问题
+type ConfigError struct {
+	Field   string // 配置项，如 cf_api_token、records[1].ip_type
+	Line    int    // 配置项所在行，0 表示未知
+	Source  string // 配置项被环境变量或命令行参数覆盖时的来源，如 env CFDDNS_INTERVAL
+	Message string
+}
+
+func (e ConfigError) Error() string {
+	if e.Source != "" {
+		return fmt.Sprintf("%s: %s: %s", e.Source, e.Field, e.Message)
+	}
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %s: %s", e.Line, e.Field, e.Message)
+	}
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ConfigErrors 配置校验发现的所有问题
+type ConfigErrors []ConfigError
+
+func (e ConfigErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d config problem(s):\n  %s", len(e), strings.Join(msgs, "\n  "))
+}
+
+// configLines 解析配置文件，返回每个配置项所在的行号
+// 键的格式与 ConfigError.Field 一致，如 cf_api_token、records[0].name，数组表本身记为 records[0]
+func configLines(data []byte) map[string]int {
+	lines := make(map[string]int)
+	arrayCounts := make(map[string]int)
+
+	var p unstable.Parser
+	p.Reset(data)
+	prefix := ""
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			key, line := tomlKey(&p, expr)
+			key = arrayTableKey(key, arrayCounts)
+			if expr.Kind == unstable.ArrayTable {
+				idx := arrayCounts[key]
+				arrayCounts[key]++
+				key = fmt.Sprintf("%s[%d]", key, idx)
+			}
+			prefix = key + "."
+			lines[key] = line
+		case unstable.KeyValue:
+			key, line := tomlKey(&p, expr)
+			lines[prefix+key] = line
+		}
+	}
+	return lines
+}
+
+// arrayTableKey 把数组表下的子表键名转换为带序号的形式
+// 如 [[notifiers]] 之后的 [notifiers.telegram] 对应 notifiers[0].telegram
+func arrayTableKey(key string, arrayCounts map[string]int) string {
+	for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key[:i], ".") {
+		if n, ok := arrayCounts[key[:i]]; ok {
+			return fmt.Sprintf("%s[%d]%s", key[:i], n-1, key[i:])
+		}
+	}
+	return key
+}
+
+// tomlKey 返回节点的完整键名及所在行号
+func tomlKey(p *unstable.Parser, node *unstable.Node) (string, int) {
+	var parts []string
+	line := 0
+	it := node.Key()
+	for it.Next() {
+		k := it.Node()
+		if line == 0 {
+			line = p.Shape(k.Raw).Start.Line
+		}
+		parts = append(parts, string(k.Data))
+	}
+	return strings.Join(parts, "."), line
+}
+
+// placeholderPattern 匹配默认配置中的占位值，如 your_CF_API_TOKEN_here
+var placeholderPattern = regexp.MustCompile(`(?i)^your_.*_here$`)
+
+// configValidator 收集校验问题
+type configValidator struct {
+	lines   map[string]int
+	sources map[string]string
+	errs    ConfigErrors
+}
+
+func (v *configValidator) add(field, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	// 多条记录继承同一个全局配置项时只报告一次
+	for _, e := range v.errs {
+		if e.Field == field && e.Message == message {
+			return
+		}
+	}
+
+	// 被覆盖的配置项报告来源，records[0].name 等按顶层配置项 records 查找
+	top, _, _ := strings.Cut(field, ".")
+	top, _, _ = strings.Cut(top, "[")
+	if source, ok := v.sources[top]; ok {
+		v.errs = append(v.errs, ConfigError{Field: field, Source: source, Message: message})
+		return
+	}
+
+	line := v.lines[field]
+	// 配置项未出现在文件中时，尝试使用所在表的行号
+	if line == 0 {
+		if i := strings.LastIndex(field, "."); i > 0 {
+			line = v.lines[field[:i]]
+		}
+	}
+	v.errs = append(v.errs, ConfigError{Field: field, Line: line, Message: message})
+}
+
+// requireValue 校验必填项，不能为空或默认配置中的占位值
+func (v *configValidator) requireValue(field, value string) {
+	switch {
+	case strings.TrimSpace(value) == "":
+		v.add(field, "is required")
+	case placeholderPattern.MatchString(value):
+		v.add(field, "still has the placeholder value %q", value)
+	}
+}
+
+// validIPType 判断 ip_type 是否为支持的值
+func validIPType(ipType string) bool {
+	return ipType == "4" || ipType == "6" || ipType == "46"
+}
+
+// Validate 校验配置，返回的 ConfigErrors 包含所有发现的问题
+func (c Config) Validate() error {
+	v := &configValidator{lines: c.lines, sources: c.sources}
+
+	v.requireValue("cf_api_token", c.CFApiToken)
+
+	if c.CFIPType != "" && !validIPType(c.CFIPType) {
+		v.add("cf_ip_type", `invalid value %q, must be "4", "6" or "46"`, c.CFIPType)
+	}
+	if c.Interval < 0 {
+		v.add("interval", "must be positive, got %d", c.Interval)
+	}
+	if c.RetryCount < 0 {
+		v.add("retry_count", "must not be negative, got %d", c.RetryCount)
+	}
+	if c.LogRetention < 0 {
+		v.add("log_retention", "must not be negative, got %d", c.LogRetention)
+	}
+	if c.ResyncInterval < 0 {
+		v.add("resync_interval", "must not be negative, got %d", c.ResyncInterval)
+	}
+	if c.ShutdownTimeout < 0 {
+		v.add("shutdown_timeout", "must not be negative, got %d", c.ShutdownTimeout)
+	}
+	if c.LogFormat != "" && c.LogFormat != "text" && c.LogFormat != "json" {
+		v.add("log_format", `invalid value %q, must be "text" or "json"`, c.LogFormat)
+	}
+
+	// HTTP API
+	if c.Listen != "" {
+		if _, _, err := net.SplitHostPort(c.Listen); err != nil {
+			v.add("listen", "invalid address %q, expected host:port such as 127.0.0.1:8053", c.Listen)
+		}
+	}
+	if c.APIToken != "" {
+		v.requireValue("api_token", c.APIToken)
+	}
+
+	// 通知
+	if c.Notify {
+		v.requireValue("tg_token", c.TGToken)
+		v.requireValue("tg_chat_id", c.TGChatID)
+		v.checkURL("tg_api_url", c.TgApiUrl)
+	}
+	names := make(map[string]string)
+	if c.Notify {
+		names[legacyNotifierName] = "tg_token"
+	}
+	for i, nc := range c.Notifiers {
+		key := fmt.Sprintf("notifiers[%d]", i)
+		for _, p := range nc.Validate() {
+			v.add(key+"."+p.Field, "%s", p.Message)
+		}
+		if nc.Name == "" {
+			continue
+		}
+		if prev, ok := names[nc.Name]; ok {
+			if prev == "tg_token" {
+				v.add(key+".name", "%q is used by the notify = true Telegram settings, choose another name", nc.Name)
+			} else {
+				v.add(key+".name", "duplicate notifier name %q, already used by %s", nc.Name, prev)
+			}
+			continue
+		}
+		names[nc.Name] = key
+	}
+
+	// 记录
+	families := make(map[string]bool)
+	if len(c.Records) == 0 {
+		v.add("cf_record_name", "no records configured, set cf_record_name or add [[records]]")
+	}
+	for i, rec := range c.Records {
+		// 兼容旧版本的单记录配置使用 cf_ 开头的配置项
+		nameField, zoneField, typeField, dupField := "cf_record_name", "cf_zone_id", "cf_ip_type", "duplicates"
+		if rec.key != "" {
+			nameField = rec.key + ".name"
+			// 记录未单独设置时继承全局配置，问题报告在全局配置项上
+			if _, ok := v.lines[rec.key+".zone_id"]; ok || c.CFZoneID == "" {
+				zoneField = rec.key + ".zone_id"
+			}
+			if _, ok := v.lines[rec.key+".ip_type"]; ok || c.CFIPType == "" {
+				typeField = rec.key + ".ip_type"
+			}
+			if _, ok := v.lines[rec.key+".duplicates"]; ok || c.Duplicates == "" {
+				dupField = rec.key + ".duplicates"
+			}
+		}
+
+		v.requireValue(nameField, rec.Name)
+		// zone_id 留空时根据记录名称自动查找
+		if rec.ZoneID != "" {
+			v.requireValue(zoneField, rec.ZoneID)
+		}
+		if !validIPType(rec.IPType) {
+			v.add(typeField, `invalid value %q, must be "4", "6" or "46"`, rec.IPType)
+		}
+		if !validDuplicates(rec.Duplicates) {
+			v.add(dupField, `invalid value %q, must be "update_first", "update_all", "replace" or "fail"`, rec.Duplicates)
+		}
+		for _, t := range ipTypesOf(rec.IPType) {
+			families[t] = true
+		}
+
+		if rec.TTL != nil && *rec.TTL != 1 && (*rec.TTL < 60 || *rec.TTL > 86400) {
+			v.add(fmt.Sprintf("records[%d].ttl", i), "must be 1 (automatic) or between 60 and 86400, got %d", *rec.TTL)
+		}
+	}
+
+	// IP 获取来源
+	if c.IPQuorum < 0 {
+		v.add("ip_quorum", "must not be negative, got %d", c.IPQuorum)
+	}
+	v.checkSources("4", "ipv4_sources", "get_ipv4_url", c.IPv4Sources, c.GetIPv4URL, c.IPQuorum, families["4"])
+	v.checkSources("6", "ipv6_sources", "get_ipv6_url", c.IPv6Sources, c.GetIPv6URL, c.IPQuorum, families["6"])
+
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// checkURL 校验 http/https 地址
+func (v *configValidator) checkURL(field, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.add(field, "invalid URL %q, must be an http:// or https:// address", value)
+	}
+}
+
+// checkSources 校验某个协议族的 IP 获取来源，used 表示是否有记录使用该协议族
+func (v *configValidator) checkSources(ipType, field, fallbackField string, sources []IPSourceConfig, fallback string, quorum int, used bool) {
+	if len(sources) == 0 {
+		if !used {
+			return
+		}
+		if fallback == "" {
+			v.add(fallbackField, "is required because a record uses IPv%s (or configure %s)", ipType, field)
+			return
+		}
+		v.checkURL(fallbackField, fallback)
+		if quorum > 1 {
+			v.add("ip_quorum", "is %d but only one IPv%s source is configured", quorum, ipType)
+		}
+		return
+	}
+
+	for i, sc := range sources {
+		key := fmt.Sprintf("%s[%d]", field, i)
+		if _, err := newIPSource(sc); err != nil {
+			v.add(key, "%v", err)
+			continue
+		}
+		if sc.Type == "" || sc.Type == "http" {
+			v.checkURL(key+".url", sc.URL)
+		}
+	}
+	if used && quorum > len(sources) {
+		v.add("ip_quorum", "is %d but only %d IPv%s source(s) are configured", quorum, len(sources), ipType)
+	}
+}
+
+// logConfigErrors 逐条记录配置问题
+func logConfigErrors(msg, path string, err error) {
+	var errs ConfigErrors
+	if !errors.As(err, &errs) {
+		slog.Error(msg, "path", path, "error", err)
+		return
+	}
+	for _, e := range errs {
+		if e.Source != "" {
+			slog.Error(msg, "source", e.Source, "field", e.Field, "error", e.Message)
+		} else {
+			slog.Error(msg, "path", path, "field", e.Field, "line", e.Line, "error", e.Message)
+		}
+	}
+}
+
+// checkConfig 执行 check 命令：校验配置并验证 Cloudflare API Token，全部通过时返回 true
+func (cf *CfDDNS) checkConfig(ctx context.Context) bool {
+	ok := true
+	if err := cf.Config.Validate(); err != nil {
+		ok = false
+		var errs ConfigErrors
+		if errors.As(err, &errs) {
+			for _, e := range errs {
+				fmt.Printf("[FAIL] %s: %s\n", cf.configPath, e.Error())
+			}
+		} else {
+			fmt.Printf("[FAIL] %s: %v\n", cf.configPath, err)
+		}
+	} else {
+		fmt.Printf("[ OK ] %s: config is valid, %d record(s) configured\n", cf.configPath, len(cf.Config.Records))
+	}
+
+	token, err := cf.api.VerifyToken(ctx)
+	switch {
+	case err != nil:
+		ok = false
+		fmt.Printf("[FAIL] Cloudflare API token: %v\n", err)
+	case token.Status != "active":
+		ok = false
+		fmt.Printf("[FAIL] Cloudflare API token: status is %q\n", token.Status)
+	default:
+		fmt.Printf("[ OK ] Cloudflare API token is active\n")
+
+		// 检查未配置 zone_id 的记录能否自动查找到 zone
+		cf.resolveZoneIDs(ctx)
+		for _, rec := range cf.Config.Records {
+			switch {
+			case rec.ZoneID == "":
+				ok = false
+				fmt.Printf("[FAIL] %s: no zone found, set zone_id manually\n", rec.Name)
+			case rec.zoneName != "":
+				fmt.Printf("[ OK ] %s: zone %s (%s)\n", rec.Name, rec.zoneName, rec.ZoneID)
+			}
+		}
+	}
+	return ok
+}
@@ -0,0 +1,83 @@
+package main
+
+import "testing"
+
+func TestConfigLines(t *testing.T) {
+	data := []byte(`cf_api_token = "t"
+interval = 60
+
+[[records]]
+name = "a.example.com"
+ttl = 300
+
+[[records]]
+name = "b.example.com"
+
+[[notifiers]]
+name = "ops"
+type = "telegram"
+[notifiers.telegram]
+token = "x"
+chat_id = "1"
+
+[[notifiers]]
+name = "hook"
+type = "webhook"
+[notifiers.webhook]
+url = "https://example.com"
+headers = { X-Api-Key = "k" }
+`)
+	lines := configLines(data)
+
+	tests := []struct {
+		key  string
+		want int
+	}{
+		{"cf_api_token", 1},
+		{"interval", 2},
+		{"records[0]", 4},
+		{"records[0].name", 5},
+		{"records[0].ttl", 6},
+		{"records[1]", 8},
+		{"records[1].name", 9},
+		{"notifiers[0]", 11},
+		{"notifiers[0].type", 13},
+		{"notifiers[0].telegram", 14},
+		{"notifiers[0].telegram.token", 15},
+		{"notifiers[0].telegram.chat_id", 16},
+		{"notifiers[1].name", 19},
+		{"notifiers[1].webhook", 21},
+		{"notifiers[1].webhook.url", 22},
+		{"notifiers[1].webhook.headers", 23},
+		// 未出现在文件中的配置项
+		{"records[1].ttl", 0},
+		{"notifiers[0].webhook", 0},
+		{"notifiers[2]", 0},
+		{"notifiers.telegram", 0},
+	}
+	for _, tt := range tests {
+		if got := lines[tt.key]; got != tt.want {
+			t.Errorf("lines[%q] = %d, want %d", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestConfigValidatorLineFallback(t *testing.T) {
+	v := configValidator{lines: configLines([]byte(`[[notifiers]]
+name = "ops"
+type = "telegram"
+[notifiers.telegram]
+chat_id = "1"
+`))}
+	// token 未出现在文件中，使用所在表 [notifiers.telegram] 的行号
+	v.add("notifiers[0].telegram.token", "is required")
+	v.add("notifiers[0].telegram.chat_id", "is required")
+	v.add("cf_api_token", "is required")
+
+	want := []int{4, 5, 0}
+	for i, e := range v.errs {
+		if e.Line != want[i] {
+			t.Errorf("%s: line = %d, want %d", e.Field, e.Line, want[i])
+		}
+	}
+}
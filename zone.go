@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"cfddns/internal/cloudflare"
+)
+
+// matchZone 在 zones 中查找记录所属的 zone，多个 zone 匹配时选择最长的后缀
+// 例如 a.dev.example.com 同时匹配 example.com 和 dev.example.com 时选择后者
+func matchZone(zones []cloudflare.Zone, recordName string) (cloudflare.Zone, bool) {
+	name := strings.ToLower(strings.TrimSuffix(recordName, "."))
+	var best cloudflare.Zone
+	for _, zone := range zones {
+		zoneName := strings.ToLower(strings.TrimSuffix(zone.Name, "."))
+		if name != zoneName && !strings.HasSuffix(name, "."+zoneName) {
+			continue
+		}
+		if len(zoneName) > len(best.Name) {
+			best = zone
+		}
+	}
+	return best, best.ID != ""
+}
+
+// resolveZoneIDs 为未配置 zone_id 的记录自动查找 zone ID
+// 优先使用状态文件中缓存的结果，否则列出 Token 可以访问的 zone 并按记录名称匹配
+// 查找失败的记录 ZoneID 保持为空，由调用方跳过
+func (cf *CfDDNS) resolveZoneIDs(ctx context.Context) {
+	var zones []cloudflare.Zone
+	listed := false
+
+	for i := range cf.Config.Records {
+		rec := &cf.Config.Records[i]
+		if rec.ZoneID != "" {
+			continue
+		}
+		if cached, ok := cf.state.getZone(rec.Name); ok {
+			rec.ZoneID, rec.zoneName = cached.ID, cached.Name
+			continue
+		}
+
+		// 同一周期内只列出一次 zone
+		if !listed {
+			var err error
+			zones, err = cf.api.ListZones(ctx)
+			if err != nil {
+				slog.Error("Failed to list zones, cannot look up zone ID", "error", err)
+				return
+			}
+			listed = true
+		}
+
+		zone, ok := matchZone(zones, rec.Name)
+		if !ok {
+			slog.Error("No zone found for record, check the record name or set zone_id", "record", rec.Name, "zones", len(zones))
+			continue
+		}
+		rec.ZoneID, rec.zoneName = zone.ID, zone.Name
+		cf.state.setZone(rec.Name, zoneState{ID: zone.ID, Name: zone.Name})
+		slog.Info("Zone ID looked up automatically.", "record", rec.Name, "zone", zone.Name, "zone_id", zone.ID)
+	}
+}
+
+// forgetZone 自动查找到的 zone 返回 403/404 时（zone 被删除、转移或 Token 失去权限）清除缓存，
+// 下个周期重新查找
+func (cf *CfDDNS) forgetZone(rec RecordConfig, err error) {
+	if rec.zoneName == "" || !(cloudflare.IsNotFound(err) || cloudflare.IsForbidden(err)) {
+		return
+	}
+	slog.Warn("Cached zone is no longer accessible, it will be looked up again.", "record", rec.Name, "zone", rec.zoneName, "zone_id", rec.ZoneID, "error", err)
+	cf.state.forgetZone(rec.Name)
+	for i := range cf.Config.Records {
+		if r := &cf.Config.Records[i]; r.Name == rec.Name && r.zoneName != "" {
+			r.ZoneID, r.zoneName = "", ""
+		}
+	}
+}
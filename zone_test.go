@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cfddns/internal/cloudflare"
+)
+
+func TestMatchZone(t *testing.T) {
+	zones := []cloudflare.Zone{
+		{ID: "z1", Name: "example.com"},
+		{ID: "z2", Name: "dev.example.com"},
+		{ID: "z3", Name: "Example.ORG."},
+		{ID: "z4", Name: "badexample.net"},
+	}
+	tests := []struct {
+		record string
+		want   string // 期望的 zone ID，空表示不匹配
+	}{
+		{"example.com", "z1"},
+		{"a.example.com", "z1"},
+		{"a.dev.example.com", "z2"},
+		{"dev.example.com", "z2"},
+		{"a.b.dev.example.com", "z2"},
+		{"a.example.com.", "z1"},
+		{"A.Example.COM", "z1"},
+		{"home.example.org", "z3"},
+		{"example.org.", "z3"},
+		{"badexample.com", ""},
+		{"a.badexample.com", ""},
+		{"example.net", ""},
+		{"x.badexample.net", "z4"},
+		{"com", ""},
+	}
+	for _, tt := range tests {
+		zone, ok := matchZone(zones, tt.record)
+		if ok != (tt.want != "") || zone.ID != tt.want {
+			t.Errorf("matchZone(%q) = %q, %v, want %q", tt.record, zone.ID, ok, tt.want)
+		}
+	}
+}
+
+func TestForgetZoneOnForbidden(t *testing.T) {
+	zoneID := "old"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/zones":
+			io.WriteString(w, `{"success":true,"result":[{"id":"`+zoneID+`","name":"example.com"}]}`)
+		case strings.HasPrefix(r.URL.Path, "/zones/old/"):
+			w.WriteHeader(http.StatusForbidden)
+			io.WriteString(w, `{"success":false,"errors":[{"code":10000,"message":"Authentication error"}]}`)
+		default:
+			io.WriteString(w, `{"success":true,"result":[]}`)
+		}
+	}))
+	defer srv.Close()
+
+	cf := newCfDDNS(Config{StateDir: t.TempDir(), Records: []RecordConfig{{Name: "a.example.com", IPType: "4"}}})
+	cf.api.BaseURL = srv.URL
+	cf.api.Retry = &cloudflare.RetryPolicy{MaxAttempts: 1}
+	ctx := context.Background()
+
+	cf.resolveZoneIDs(ctx)
+	if got := cf.Config.Records[0].ZoneID; got != "old" {
+		t.Fatalf("zone ID = %q, want old", got)
+	}
+
+	// zone 转移到其他账号后旧 ID 返回 403，应清除缓存并重新查找
+	zoneID = "new"
+	if _, err := cf.lookupDNSRecords(ctx, cf.Config.Records[0], "4"); !cloudflare.IsForbidden(err) {
+		t.Fatalf("lookup error = %v, want 403", err)
+	}
+	if _, ok := cf.state.getZone("a.example.com"); ok {
+		t.Error("cached zone was not removed")
+	}
+	if got := cf.Config.Records[0].ZoneID; got != "" {
+		t.Errorf("zone ID = %q after 403, want empty", got)
+	}
+
+	cf.resolveZoneIDs(ctx)
+	if got := cf.Config.Records[0].ZoneID; got != "new" {
+		t.Errorf("zone ID = %q after lookup, want new", got)
+	}
+	if _, err := cf.lookupDNSRecords(ctx, cf.Config.Records[0], "4"); err != nil {
+		t.Errorf("lookup error = %v", err)
+	}
+}
+
+func TestForgetZoneKeepsConfiguredZone(t *testing.T) {
+	cf := newCfDDNS(Config{StateDir: t.TempDir(), Records: []RecordConfig{{ZoneID: "manual", Name: "a.example.com", IPType: "4"}}})
+	cf.forgetZone(cf.Config.Records[0], &cloudflare.Error{StatusCode: http.StatusNotFound})
+	if got := cf.Config.Records[0].ZoneID; got != "manual" {
+		t.Errorf("configured zone ID = %q, want manual", got)
+	}
+}